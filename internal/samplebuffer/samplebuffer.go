@@ -0,0 +1,157 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+// Package samplebuffer provides a small RTP sequence-number reordering
+// buffer shared by ivfwriter and oggwriter's WithJitterBuffer option.
+package samplebuffer
+
+import (
+	"time"
+
+	"github.com/pion/rtp"
+)
+
+// SampleBuffer reorders RTP packets by sequence number before a writer
+// consumes them, so a brief run of out-of-order packets doesn't get spliced
+// into a frame in the wrong order or turn into a negative granule-position
+// delta. Packets are released in sequence order once either maxDelay has
+// passed since the oldest buffered packet arrived or maxPackets packets are
+// buffered, whichever comes first; any sequence number skipped over when
+// that happens is reported via OnLostPacket.
+type SampleBuffer struct {
+	maxDelay   time.Duration
+	maxPackets int
+
+	packets map[uint16]sampleBufferEntry
+	started bool
+	nextSeq uint16
+	onLost  func(sequenceNumber uint16)
+}
+
+type sampleBufferEntry struct {
+	packet  *rtp.Packet
+	arrived time.Time
+}
+
+// NewSampleBuffer creates a SampleBuffer that holds at most maxPackets
+// packets and releases the oldest one after maxDelay even if a gap in
+// sequence numbers is still unfilled.
+func NewSampleBuffer(maxDelay time.Duration, maxPackets int) *SampleBuffer {
+	return &SampleBuffer{
+		maxDelay:   maxDelay,
+		maxPackets: maxPackets,
+		packets:    map[uint16]sampleBufferEntry{},
+	}
+}
+
+// OnLostPacket registers a callback invoked for every sequence number the
+// SampleBuffer gives up waiting for.
+func (b *SampleBuffer) OnLostPacket(f func(sequenceNumber uint16)) {
+	b.onLost = f
+}
+
+// Push adds packet to the buffer.
+func (b *SampleBuffer) Push(packet *rtp.Packet) {
+	b.packets[packet.SequenceNumber] = sampleBufferEntry{packet: packet, arrived: time.Now()}
+}
+
+// Pop returns the next packet in sequence order, if the buffer has decided
+// it's ready to release it.
+func (b *SampleBuffer) Pop() (*rtp.Packet, bool) {
+	if !b.ensureStarted() {
+		return nil, false
+	}
+
+	for {
+		entry, present := b.packets[b.nextSeq]
+		if present {
+			delete(b.packets, b.nextSeq)
+			b.nextSeq++
+
+			return entry.packet, true
+		}
+
+		if len(b.packets) == 0 || (len(b.packets) < b.maxPackets && !b.oldestExceedsMaxDelay()) {
+			return nil, false
+		}
+
+		// Give up waiting for b.nextSeq: it's either lost or arrived so
+		// late the buffer had to move on without it.
+		if b.onLost != nil {
+			b.onLost(b.nextSeq)
+		}
+		b.nextSeq++
+	}
+}
+
+// Flush releases every packet still buffered, in sequence order, bypassing
+// the maxDelay/maxPackets wait Pop would otherwise apply; any gap still
+// unfilled is reported via OnLostPacket just as Pop would. Intended for a
+// writer's Close, where no further packets will ever arrive to fill a gap.
+func (b *SampleBuffer) Flush() []*rtp.Packet {
+	if !b.ensureStarted() {
+		return nil
+	}
+
+	var out []*rtp.Packet
+	for len(b.packets) > 0 {
+		entry, present := b.packets[b.nextSeq]
+		if present {
+			delete(b.packets, b.nextSeq)
+			out = append(out, entry.packet)
+		} else if b.onLost != nil {
+			b.onLost(b.nextSeq)
+		}
+		b.nextSeq++
+	}
+
+	return out
+}
+
+// ensureStarted anchors nextSeq on the lowest sequence number currently
+// pending the first time it's needed, rather than whichever packet happened
+// to be pushed first: push order and arrival order aren't the same thing
+// for a buffer whose whole purpose is tolerating out-of-order arrival. It
+// reports false if the buffer has never had anything pushed to it.
+func (b *SampleBuffer) ensureStarted() bool {
+	if b.started {
+		return true
+	}
+
+	lowest, ok := b.lowestPendingSeq()
+	if !ok {
+		return false
+	}
+	b.started = true
+	b.nextSeq = lowest
+
+	return true
+}
+
+// lowestPendingSeq returns the smallest sequence number currently buffered.
+func (b *SampleBuffer) lowestPendingSeq() (uint16, bool) {
+	var lowest uint16
+	found := false
+	for seq := range b.packets {
+		if !found || seq < lowest {
+			lowest = seq
+			found = true
+		}
+	}
+
+	return lowest, found
+}
+
+func (b *SampleBuffer) oldestExceedsMaxDelay() bool {
+	if b.maxDelay <= 0 {
+		return false
+	}
+
+	for _, entry := range b.packets {
+		if time.Since(entry.arrived) >= b.maxDelay {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,103 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package samplebuffer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+)
+
+func TestSampleBufferReordersBySequenceNumber(t *testing.T) {
+	b := NewSampleBuffer(0, 10)
+
+	b.Push(&rtp.Packet{Header: rtp.Header{SequenceNumber: 2}})
+	b.Push(&rtp.Packet{Header: rtp.Header{SequenceNumber: 0}})
+	b.Push(&rtp.Packet{Header: rtp.Header{SequenceNumber: 1}})
+
+	var got []uint16
+	for {
+		packet, ok := b.Pop()
+		if !ok {
+			break
+		}
+		got = append(got, packet.SequenceNumber)
+	}
+
+	want := []uint16{0, 1, 2}
+	if len(got) != len(want) {
+		t.Fatalf("Pop order = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Pop order = %v, want %v", got, want)
+		}
+	}
+}
+
+// TestSampleBufferReleasesOnMaxPackets pushes a sequence-number gap, then
+// verifies the buffer waits for it up to maxPackets before giving up and
+// reporting it lost.
+func TestSampleBufferReleasesOnMaxPackets(t *testing.T) {
+	b := NewSampleBuffer(0, 2)
+
+	var lost []uint16
+	b.OnLostPacket(func(seq uint16) { lost = append(lost, seq) })
+
+	b.Push(&rtp.Packet{Header: rtp.Header{SequenceNumber: 0}})
+	packet, ok := b.Pop()
+	if !ok || packet.SequenceNumber != 0 {
+		t.Fatalf("Pop = (%v, %v), want (0, true)", packet, ok)
+	}
+
+	// Sequence 1 is now missing; only one packet (2) is pending, below
+	// maxPackets, so the buffer should keep waiting.
+	b.Push(&rtp.Packet{Header: rtp.Header{SequenceNumber: 2}})
+	if _, ok := b.Pop(); ok {
+		t.Fatal("Pop should not release before maxPackets is reached")
+	}
+
+	// A third packet pushes the pending count to maxPackets (2); the
+	// buffer must give up on sequence 1 and release sequence 2 instead.
+	b.Push(&rtp.Packet{Header: rtp.Header{SequenceNumber: 3}})
+	packet, ok = b.Pop()
+	if !ok {
+		t.Fatal("Pop should release sequence 2 once maxPackets is reached")
+	}
+	if packet.SequenceNumber != 2 {
+		t.Fatalf("Pop returned sequence %d, want 2", packet.SequenceNumber)
+	}
+	if len(lost) != 1 || lost[0] != 1 {
+		t.Fatalf("OnLostPacket reported %v, want [1]", lost)
+	}
+}
+
+// TestSampleBufferReleasesOnMaxDelay is the same scenario as
+// TestSampleBufferReleasesOnMaxPackets but bounded by elapsed time instead
+// of pending packet count.
+func TestSampleBufferReleasesOnMaxDelay(t *testing.T) {
+	b := NewSampleBuffer(2*time.Millisecond, 100)
+
+	b.Push(&rtp.Packet{Header: rtp.Header{SequenceNumber: 0}})
+	packet, ok := b.Pop()
+	if !ok || packet.SequenceNumber != 0 {
+		t.Fatalf("Pop = (%v, %v), want (0, true)", packet, ok)
+	}
+
+	b.Push(&rtp.Packet{Header: rtp.Header{SequenceNumber: 2}})
+	if _, ok := b.Pop(); ok {
+		t.Fatal("Pop should not release before maxDelay elapses")
+	}
+
+	time.Sleep(3 * time.Millisecond)
+
+	packet, ok = b.Pop()
+	if !ok {
+		t.Fatal("Pop should release sequence 2 once maxDelay has elapsed")
+	}
+	if packet.SequenceNumber != 2 {
+		t.Fatalf("Pop returned sequence %d, want 2", packet.SequenceNumber)
+	}
+}
@@ -0,0 +1,297 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+// Package ivfreader implements the IVF media container reader
+package ivfreader
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	ivfFileHeaderSignature = "DKIF"
+	ivfFileHeaderSize      = 32
+	ivfFrameHeaderSize     = 20
+)
+
+var (
+	errNilReader        = errors.New("reader is nil")
+	errBadFileSignature = errors.New("bad file signature")
+	errUnsupportedVer   = errors.New("unsupported IVF file version")
+	errOffsetsNotLoaded = errors.New("offsets sidecar not loaded or empty")
+	errNoSeekableStream = errors.New("underlying stream is not seekable")
+)
+
+// FileHeader holds the metadata parsed from an IVF file's 32-byte header.
+type FileHeader struct {
+	Version        uint16
+	HeaderSize     uint16
+	FourCC         string
+	Width          uint16
+	Height         uint16
+	FramerateDenom uint32
+	FramerateNum   uint32
+	FrameCount     uint32
+}
+
+// Reader reads frames from an IVF file written by ivfwriter.IVFWriter. It
+// implements media.MediaReader, so SeekToTime can jump to the nearest
+// offset recorded in the "-offsets.json" sidecar ivfwriter.New writes
+// alongside the IVF file, then resume frame parsing from there.
+type Reader struct {
+	stream io.ReadSeeker
+	Header FileHeader
+	isAV1  bool
+
+	offsetsFileName string
+	offsets         map[string]int64
+
+	// Set after SeekToTime, since landing mid-stream may not put us on a
+	// key frame; Next then scans forward until it finds one.
+	needKeyFrame bool
+}
+
+// NewWith creates a new Reader over an already-open io.ReadSeeker.
+func NewWith(in io.ReadSeeker) (*Reader, *FileHeader, error) {
+	if in == nil {
+		return nil, nil, errNilReader
+	}
+
+	reader := &Reader{stream: in}
+	if err := reader.parseFileHeader(); err != nil {
+		return nil, nil, err
+	}
+
+	return reader, &reader.Header, nil
+}
+
+// New opens fileName for reading and wires up the "-offsets.json" sidecar
+// ivfwriter.New writes next to it, so SeekToTime can be used.
+func New(fileName string) (*Reader, *FileHeader, error) {
+	f, err := os.Open(fileName) //nolint:gosec
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reader, header, err := NewWith(f)
+	if err != nil {
+		return nil, nil, err
+	}
+	reader.offsetsFileName = strings.Split(fileName, ".")[0] + "-offsets.json"
+
+	return reader, header, nil
+}
+
+func (r *Reader) parseFileHeader() error {
+	buf := make([]byte, ivfFileHeaderSize)
+	if _, err := io.ReadFull(r.stream, buf); err != nil {
+		return err
+	}
+
+	if string(buf[0:4]) != ivfFileHeaderSignature {
+		return errBadFileSignature
+	}
+
+	r.Header = FileHeader{
+		Version:        binary.LittleEndian.Uint16(buf[4:]),
+		HeaderSize:     binary.LittleEndian.Uint16(buf[6:]),
+		FourCC:         string(buf[8:12]),
+		Width:          binary.LittleEndian.Uint16(buf[12:]),
+		Height:         binary.LittleEndian.Uint16(buf[14:]),
+		FramerateDenom: binary.LittleEndian.Uint32(buf[16:]),
+		FramerateNum:   binary.LittleEndian.Uint32(buf[20:]),
+		FrameCount:     binary.LittleEndian.Uint32(buf[24:]),
+	}
+	if r.Header.Version != 0 {
+		return errUnsupportedVer
+	}
+	r.isAV1 = r.Header.FourCC == "AV01"
+
+	return nil
+}
+
+// ParseNextFrame reads the next frame header and payload, as written by
+// ivfwriter.IVFWriter.writeFrame: a 20-byte header (length, PTS, duration in
+// milliseconds) followed by the frame bytes.
+func (r *Reader) ParseNextFrame() (frame []byte, pts uint64, err error) {
+	header := make([]byte, ivfFrameHeaderSize)
+	if _, err := io.ReadFull(r.stream, header); err != nil {
+		return nil, 0, err
+	}
+
+	frameLen := binary.LittleEndian.Uint32(header[0:])
+	pts = binary.LittleEndian.Uint64(header[4:])
+
+	frame = make([]byte, frameLen)
+	if _, err := io.ReadFull(r.stream, frame); err != nil {
+		return nil, 0, err
+	}
+
+	return frame, pts, nil
+}
+
+// Next implements media.MediaReader. If a prior SeekToTime landed mid
+// stream, it scans forward to the next key frame so random-access playback
+// actually decodes.
+func (r *Reader) Next() ([]byte, time.Duration, error) {
+	for {
+		frame, pts, err := r.ParseNextFrame()
+		if err != nil {
+			return nil, 0, err
+		}
+
+		if r.needKeyFrame && !isKeyFrame(frame, r.isAV1) {
+			continue
+		}
+		r.needKeyFrame = false
+
+		return frame, time.Duration(pts) * time.Millisecond, nil
+	}
+}
+
+// isKeyFrame reports whether frame begins a new key frame, per-codec.
+func isKeyFrame(frame []byte, isAV1 bool) bool {
+	if len(frame) == 0 {
+		return false
+	}
+	if isAV1 {
+		return av1FrameHasKeyFrame(frame)
+	}
+
+	// VP8 uncompressed data chunk: the low bit of the first byte is 0 for
+	// a key frame, 1 for an interframe.
+	return frame[0]&0x01 == 0
+}
+
+// av1FrameHasKeyFrame scans the OBUs written for one IVF frame and reports
+// whether an OBU_SEQUENCE_HEADER (type 1) appears in it. Encoders only emit
+// a sequence header ahead of a key frame, so its presence is a reliable,
+// if coarse, key-frame signal.
+//
+// A precise check would instead read the show_frame bit out of the
+// following OBU_FRAME's frame_header, but that bit's position depends on
+// seq_header.reduced_still_picture_header, which itself requires bit-level
+// parsing of the sequence header OBU that isn't implemented here. Treating
+// "has a sequence header" as "is a key frame" is the accepted simplification
+// until that parsing exists.
+func av1FrameHasKeyFrame(frame []byte) bool {
+	const obuSequenceHeader = 1
+
+	for offset := 0; offset < len(frame); {
+		header := frame[offset]
+		obuType := (header >> 3) & 0x0F
+		hasExtension := (header>>2)&0x01 == 1
+		hasSize := (header>>1)&0x01 == 1
+
+		pos := offset + 1
+		if hasExtension {
+			pos++
+		}
+		if !hasSize || pos >= len(frame) {
+			break
+		}
+
+		size, n := readLEB128(frame[pos:])
+		if n == 0 {
+			break
+		}
+
+		if obuType == obuSequenceHeader {
+			return true
+		}
+
+		offset = pos + n + int(size)
+	}
+
+	return false
+}
+
+// readLEB128 decodes an AV1 leb128-encoded unsigned integer, returning the
+// value and the number of bytes it occupied, or 0 if buf is truncated.
+func readLEB128(buf []byte) (uint64, int) {
+	var value uint64
+	for i := 0; i < len(buf) && i < 8; i++ {
+		value |= uint64(buf[i]&0x7f) << (7 * i)
+		if buf[i]&0x80 == 0 {
+			return value, i + 1
+		}
+	}
+
+	return 0, 0
+}
+
+// SeekToTime implements media.MediaReader. It loads the "-offsets.json"
+// sidecar on first use and seeks the underlying stream to the nearest
+// indexed byte offset at or before d.
+func (r *Reader) SeekToTime(d time.Duration) error {
+	if r.offsets == nil {
+		if err := r.loadOffsets(); err != nil {
+			return err
+		}
+	}
+
+	target := d.Milliseconds()
+	var bestOffset int64 = ivfFileHeaderSize
+	var bestMs int64 = -1
+	for msStr, byteOffset := range r.offsets {
+		ms, err := strconv.ParseInt(msStr, 10, 64)
+		if err != nil {
+			continue
+		}
+		if ms <= target && ms > bestMs {
+			bestMs = ms
+			bestOffset = byteOffset + ivfFileHeaderSize
+		}
+	}
+
+	seeker, ok := r.stream.(io.Seeker)
+	if !ok {
+		return errNoSeekableStream
+	}
+	if _, err := seeker.Seek(bestOffset, io.SeekStart); err != nil {
+		return err
+	}
+
+	r.needKeyFrame = true
+
+	return nil
+}
+
+func (r *Reader) loadOffsets() error {
+	if r.offsetsFileName == "" {
+		return errOffsetsNotLoaded
+	}
+
+	data, err := os.ReadFile(r.offsetsFileName) //nolint:gosec
+	if err != nil {
+		return err
+	}
+
+	offsets := map[string]int64{}
+	if err := json.Unmarshal(data, &offsets); err != nil {
+		return err
+	}
+	if len(offsets) == 0 {
+		return errOffsetsNotLoaded
+	}
+
+	r.offsets = offsets
+
+	return nil
+}
+
+// Close closes the underlying stream, if it implements io.Closer.
+func (r *Reader) Close() error {
+	if closer, ok := r.stream.(io.Closer); ok {
+		return closer.Close()
+	}
+
+	return nil
+}
@@ -0,0 +1,69 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package ivfreader
+
+import "testing"
+
+func TestReadLEB128(t *testing.T) {
+	cases := []struct {
+		name      string
+		buf       []byte
+		wantValue uint64
+		wantN     int
+	}{
+		{"zero", []byte{0x00}, 0, 1},
+		{"maxSingleByte", []byte{0x7f}, 0x7f, 1},
+		{"needsContinuation", []byte{0x80, 0x01}, 0x80, 2},
+		{"threeHundred", []byte{0xac, 0x02}, 300, 2},
+		{"truncated", []byte{0x80}, 0, 0},
+		{"empty", []byte{}, 0, 0},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			value, n := readLEB128(c.buf)
+			if value != c.wantValue || n != c.wantN {
+				t.Fatalf("readLEB128(%#v) = (%d, %d), want (%d, %d)", c.buf, value, n, c.wantValue, c.wantN)
+			}
+		})
+	}
+}
+
+func TestAV1FrameHasKeyFrame(t *testing.T) {
+	// OBU header: (type<<3)|(ext<<2)|(hasSize<<1). Type 1 = OBU_SEQUENCE_HEADER,
+	// type 6 = OBU_FRAME.
+	const obuSequenceHeaderType = 1 << 3
+	const obuFrameType = 6 << 3
+	const hasSizeBit = 1 << 1
+
+	cases := []struct {
+		name  string
+		frame []byte
+		want  bool
+	}{
+		{"sequence header present", []byte{obuSequenceHeaderType | hasSizeBit, 0x00}, true},
+		{"no sequence header", []byte{obuFrameType | hasSizeBit, 0x00}, false},
+		{"empty frame", []byte{}, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := av1FrameHasKeyFrame(c.frame); got != c.want {
+				t.Fatalf("av1FrameHasKeyFrame(%#v) = %v, want %v", c.frame, got, c.want)
+			}
+		})
+	}
+}
+
+func TestIsKeyFrame(t *testing.T) {
+	if !isKeyFrame([]byte{0x00}, false) {
+		t.Fatal("VP8 frame with low bit 0 should be a key frame")
+	}
+	if isKeyFrame([]byte{0x01}, false) {
+		t.Fatal("VP8 frame with low bit 1 should not be a key frame")
+	}
+	if isKeyFrame(nil, false) {
+		t.Fatal("empty frame should not be a key frame")
+	}
+}
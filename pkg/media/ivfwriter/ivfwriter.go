@@ -18,6 +18,7 @@ import (
 	"github.com/pion/rtp"
 	"github.com/pion/rtp/codecs"
 	"github.com/pion/rtp/codecs/av1/frame"
+	"github.com/pion/webrtc/v3/internal/samplebuffer"
 )
 
 var (
@@ -32,6 +33,11 @@ const (
 	mimeTypeAV1 = "video/AV1"
 
 	ivfFileHeaderSignature = "DKIF"
+
+	// defaultClockRate is the RTP clock rate both VP8 and AV1 payloaders
+	// use per their respective RFCs, and is what frame durations are
+	// computed against in the absence of a way to signal a different rate.
+	defaultClockRate = 90000
 )
 
 // IVFWriter is used to take RTP packets and write them to an IVF on disk
@@ -43,17 +49,26 @@ type IVFWriter struct {
 	isVP8, isAV1 bool
 
 	// VP8
-	currentFrame []byte
+	currentFrame          []byte
+	currentFrameTimestamp uint32
 
 	// AV1
-	av1Frame      frame.AV1
-	log           logging.LeveledLogger
-	lastFrameTime int64
+	av1Frame frame.AV1
+	log      logging.LeveledLogger
+
+	// Reordering, enabled via WithJitterBuffer.
+	jitterBuffer *samplebuffer.SampleBuffer
+	onPacketLost func(sequenceNumber uint16)
+
+	// Presentation time, derived from RTP timestamps rather than wall
+	// clock so frame duration survives jitter and reordering.
+	lastRTPTimestamp   uint32
+	haveRTPTimestamp   bool
+	presentationTimeMs int64
 
 	offsetsfileName string
 	// used for seek indexing
 	timeOffsetMap           map[string]int64
-	timeElapsedMilliCounter int64
 	bytesAccumulatedCounter int64
 }
 
@@ -80,8 +95,9 @@ func NewWith(out io.Writer, opts ...Option) (*IVFWriter, error) {
 	}
 
 	writer := &IVFWriter{
-		ioWriter:     out,
-		seenKeyFrame: false,
+		ioWriter:      out,
+		seenKeyFrame:  false,
+		timeOffsetMap: map[string]int64{},
 	}
 
 	for _, o := range opts {
@@ -124,34 +140,33 @@ func (i *IVFWriter) writeHeader() error {
 	return err
 }
 
-func (i *IVFWriter) writeFrame(frame []byte) error {
-	if i.count == 0 {
-		i.lastFrameTime = time.Now().UnixMilli()
-		i.bytesAccumulatedCounter = 0
-		i.timeElapsedMilliCounter = 0
-		i.timeOffsetMap[strconv.Itoa(int(i.timeElapsedMilliCounter))] = i.bytesAccumulatedCounter
+// writeFrame writes one frame, deriving its duration from the delta between
+// rtpTimestamp and the previous frame's, scaled by defaultClockRate, rather
+// than wall-clock time, so duration reflects the media timeline instead of
+// however fast packets happened to be processed.
+func (i *IVFWriter) writeFrame(frame []byte, rtpTimestamp uint32) error {
+	var durationMs uint64
+	if i.haveRTPTimestamp {
+		durationMs = uint64(rtpTimestamp-i.lastRTPTimestamp) * 1000 / defaultClockRate
 	}
-
-	currTime := time.Now().UnixMilli()
-	durationSinceLastFrame := uint64(currTime - i.lastFrameTime)
+	i.lastRTPTimestamp = rtpTimestamp
+	i.haveRTPTimestamp = true
+	i.presentationTimeMs += int64(durationMs)
 
 	headerBytes := 20
 	frameHeader := make([]byte, headerBytes)
-	binary.LittleEndian.PutUint32(frameHeader[0:], uint32(len(frame)))      // Frame length
-	binary.LittleEndian.PutUint64(frameHeader[4:], i.count)                 // PTS
-	binary.LittleEndian.PutUint64(frameHeader[12:], durationSinceLastFrame) // Millisecond
-	// frameHeader := make([]byte, 12)
-	// binary.LittleEndian.PutUint32(frameHeader[0:], uint32(len(frame))) // Frame length
-	// binary.LittleEndian.PutUint64(frameHeader[4:], i.count)            // PTS
+	binary.LittleEndian.PutUint32(frameHeader[0:], uint32(len(frame)))           // Frame length
+	binary.LittleEndian.PutUint64(frameHeader[4:], uint64(i.presentationTimeMs)) // PTS, in milliseconds
+	binary.LittleEndian.PutUint64(frameHeader[12:], durationMs)                  // Millisecond
 
 	i.count++
-	i.lastFrameTime = currTime
 
-	// time to offset map
+	// time to offset map, keyed by RTP presentation time rather than wall
+	// clock so SeekToTime lines up with the media timeline. Recorded before
+	// accumulating this frame's own bytes, so the stored offset points at
+	// the frame's own header rather than the frame after it.
+	i.timeOffsetMap[strconv.FormatInt(i.presentationTimeMs, 10)] = i.bytesAccumulatedCounter
 	i.bytesAccumulatedCounter = i.bytesAccumulatedCounter + int64(headerBytes) + int64(len(frame))
-	i.timeElapsedMilliCounter = i.timeElapsedMilliCounter + int64(durationSinceLastFrame)
-	i.timeOffsetMap[strconv.Itoa(int(i.timeElapsedMilliCounter))] = i.bytesAccumulatedCounter
-	// i.log.Errorf("writeFrame Len: %#v, count: %#v offset: %#v", len(frame), int64(i.count), currTime-i.lastFrameTime)
 
 	if _, err := i.ioWriter.Write(frameHeader); err != nil {
 		return err
@@ -160,11 +175,40 @@ func (i *IVFWriter) writeFrame(frame []byte) error {
 	return err
 }
 
-// WriteRTP adds a new packet and writes the appropriate headers for it
+// WriteRTP adds a new packet and writes the appropriate headers for it. If
+// WithJitterBuffer was used, packets are first reordered by sequence
+// number and released once either the configured max delay or max-packet
+// high-water mark is reached; any sequence number the buffer gives up
+// waiting for is reported via WithOnPacketLost and discards the in-flight
+// frame, since a VP8/AV1 frame split across a lost packet can't be
+// reassembled.
 func (i *IVFWriter) WriteRTP(packet *rtp.Packet) error {
 	if i.ioWriter == nil {
 		return errFileNotOpened
-	} else if len(packet.Payload) == 0 {
+	} else if packet == nil {
+		return errInvalidNilPacket
+	}
+
+	if i.jitterBuffer == nil {
+		return i.processRTP(packet)
+	}
+
+	i.jitterBuffer.Push(packet)
+	for {
+		next, ok := i.jitterBuffer.Pop()
+		if !ok {
+			return nil
+		}
+		if err := i.processRTP(next); err != nil {
+			return err
+		}
+	}
+}
+
+// processRTP contains the per-codec framing logic that turns RTP packets,
+// taken in sequence order, into IVF frames.
+func (i *IVFWriter) processRTP(packet *rtp.Packet) error {
+	if len(packet.Payload) == 0 {
 		return nil
 	}
 
@@ -182,6 +226,10 @@ func (i *IVFWriter) WriteRTP(packet *rtp.Packet) error {
 			return nil
 		}
 
+		if i.currentFrame == nil {
+			i.currentFrameTimestamp = packet.Timestamp
+		}
+
 		i.seenKeyFrame = true
 		i.currentFrame = append(i.currentFrame, vp8Packet.Payload[0:]...)
 
@@ -191,7 +239,7 @@ func (i *IVFWriter) WriteRTP(packet *rtp.Packet) error {
 			return nil
 		}
 
-		if err := i.writeFrame(i.currentFrame); err != nil {
+		if err := i.writeFrame(i.currentFrame, i.currentFrameTimestamp); err != nil {
 			return err
 		}
 		i.currentFrame = nil
@@ -207,7 +255,7 @@ func (i *IVFWriter) WriteRTP(packet *rtp.Packet) error {
 		}
 
 		for j := range obus {
-			if err := i.writeFrame(obus[j]); err != nil {
+			if err := i.writeFrame(obus[j], packet.Timestamp); err != nil {
 				return err
 			}
 		}
@@ -228,6 +276,17 @@ func (i *IVFWriter) Close() error {
 		i.ioWriter = nil
 	}()
 
+	// Drain any packets WithJitterBuffer is still holding back waiting for
+	// a gap to fill; no further packets are coming, so process them now
+	// rather than silently dropping the tail of the recording.
+	if i.jitterBuffer != nil {
+		for _, packet := range i.jitterBuffer.Flush() {
+			if err := i.processRTP(packet); err != nil {
+				return err
+			}
+		}
+	}
+
 	jsonString, err := json.Marshal(i.timeOffsetMap)
 	if err != nil {
 		return err
@@ -283,3 +342,40 @@ func WithCodec(mimeType string) Option {
 		return nil
 	}
 }
+
+// WithJitterBuffer enables a samplebuffer.SampleBuffer in front of
+// WriteRTP: packets are reordered by sequence number and released in order
+// once either maxDelay has passed or maxPackets packets have accumulated,
+// whichever comes first. Use WithOnPacketLost to be notified when the
+// buffer gives up waiting for a packet.
+func WithJitterBuffer(maxDelay time.Duration, maxPackets int) Option {
+	return func(i *IVFWriter) error {
+		i.jitterBuffer = samplebuffer.NewSampleBuffer(maxDelay, maxPackets)
+		i.jitterBuffer.OnLostPacket(func(sequenceNumber uint16) {
+			// The in-flight frame can't be completed without this packet;
+			// drop it rather than write a corrupt one. For AV1, av1Frame
+			// also holds its own internal fragment cache across packets, so
+			// it needs to be reset too or the next OBU gets spliced onto a
+			// stale fragment from before the gap.
+			i.currentFrame = nil
+			i.av1Frame = frame.AV1{}
+			if i.onPacketLost != nil {
+				i.onPacketLost(sequenceNumber)
+			}
+		})
+
+		return nil
+	}
+}
+
+// WithOnPacketLost sets a callback invoked with the sequence number of
+// every RTP packet a jitter buffer enabled via WithJitterBuffer gives up
+// waiting for, so callers can request a PLI upstream. Has no effect unless
+// WithJitterBuffer is also used.
+func WithOnPacketLost(f func(sequenceNumber uint16)) Option {
+	return func(i *IVFWriter) error {
+		i.onPacketLost = f
+
+		return nil
+	}
+}
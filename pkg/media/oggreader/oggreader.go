@@ -0,0 +1,351 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+// Package oggreader implements the OGG media container reader
+package oggreader
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	pageHeaderSignature  = "OggS"
+	pageHeaderSize       = 27
+	idPageSignature      = "OpusHead"
+	commentPageSignature = "OpusTags"
+
+	// Opus' granule position always advances at 48kHz, regardless of the
+	// input sample rate (RFC 7845 Section 4).
+	opusGranuleRate = 48000
+)
+
+var (
+	errNilReader        = errors.New("reader is nil")
+	errBadPageSignature = errors.New("bad page signature")
+	errBadPageChecksum  = errors.New("expected and actual checksum do not match")
+	errOffsetsNotLoaded = errors.New("offsets sidecar not loaded or empty")
+	errNoSeekableStream = errors.New("underlying stream is not seekable")
+)
+
+// OggHeader describes the file-level Opus parameters parsed from the ID
+// header page.
+type OggHeader struct {
+	ChannelMap uint8
+	Channels   uint8
+	OutputGain uint16
+	PreSkip    uint16
+	SampleRate uint32
+	Version    uint8
+}
+
+type playOffset struct {
+	TimeOffset  int64 `json:"time"`
+	BytesOffset int64 `json:"bytes"`
+}
+
+// Reader reads pages from an Ogg/Opus file written by oggwriter.OggWriter.
+// It implements media.MediaReader: SeekToTime jumps to the nearest byte
+// offset recorded in the "-offsets.json" sidecar, then scans forward for
+// the next valid "OggS" page, verifying its CRC, before resuming.
+type Reader struct {
+	stream        io.ReadSeeker
+	checksumTable *[256]uint32
+	opusSerial    uint32
+
+	offsetsFileName string
+	offsets         []*playOffset
+}
+
+// NewWith creates a new Reader over an already-open io.ReadSeeker and parses
+// the Opus ID and comment header pages.
+func NewWith(in io.ReadSeeker) (*Reader, *OggHeader, error) {
+	if in == nil {
+		return nil, nil, errNilReader
+	}
+
+	reader := &Reader{stream: in, checksumTable: generateChecksumTable()}
+	header, err := reader.parseFileHeaders()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return reader, header, nil
+}
+
+// New opens fileName for reading and wires up the "-offsets.json" sidecar
+// oggwriter.New writes alongside it, so SeekToTime can be used.
+func New(fileName string) (*Reader, *OggHeader, error) {
+	f, err := os.Open(fileName) //nolint:gosec
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reader, header, err := NewWith(f)
+	if err != nil {
+		return nil, nil, err
+	}
+	reader.offsetsFileName = strings.Split(fileName, ".")[0] + "-offsets.json"
+
+	return reader, header, nil
+}
+
+// parseFileHeaders reads pages until it finds the Opus ID header, then
+// until it finds the comment header, tolerating any other logical streams
+// (such as an Ogg Skeleton seek index) multiplexed in between.
+func (o *Reader) parseFileHeaders() (*OggHeader, error) {
+	var header *OggHeader
+	for header == nil {
+		payload, _, serial, _, err := o.parseNextPage()
+		if err != nil {
+			return nil, err
+		}
+		if len(payload) != 19 || string(payload[0:8]) != idPageSignature {
+			continue
+		}
+
+		header = &OggHeader{
+			Version:    payload[8],
+			Channels:   payload[9],
+			PreSkip:    binary.LittleEndian.Uint16(payload[10:]),
+			SampleRate: binary.LittleEndian.Uint32(payload[12:]),
+			OutputGain: binary.LittleEndian.Uint16(payload[16:]),
+			ChannelMap: payload[18],
+		}
+		o.opusSerial = serial
+	}
+
+	for {
+		payload, _, serial, _, err := o.parseNextPage()
+		if err != nil {
+			return nil, err
+		}
+		if serial == o.opusSerial && len(payload) >= 8 && string(payload[0:8]) == commentPageSignature {
+			return header, nil
+		}
+	}
+}
+
+// parseNextPage reads one Ogg page at the stream's current position,
+// validating its CRC, and returns its payload, granule position, serial
+// number and page sequence number.
+func (o *Reader) parseNextPage() (payload []byte, granulePos uint64, serial, pageIndex uint32, err error) {
+	header := make([]byte, pageHeaderSize)
+	if _, err := io.ReadFull(o.stream, header); err != nil {
+		return nil, 0, 0, 0, err
+	}
+	if string(header[0:4]) != pageHeaderSignature {
+		return nil, 0, 0, 0, errBadPageSignature
+	}
+
+	segmentTable := make([]byte, header[26])
+	if _, err := io.ReadFull(o.stream, segmentTable); err != nil {
+		return nil, 0, 0, 0, err
+	}
+
+	payloadSize := 0
+	for _, s := range segmentTable {
+		payloadSize += int(s)
+	}
+	payload = make([]byte, payloadSize)
+	if _, err := io.ReadFull(o.stream, payload); err != nil {
+		return nil, 0, 0, 0, err
+	}
+
+	expectedChecksum := binary.LittleEndian.Uint32(header[22:])
+	if checksum := o.pageChecksum(header, segmentTable, payload); checksum != expectedChecksum {
+		return nil, 0, 0, 0, errBadPageChecksum
+	}
+
+	granulePos = binary.LittleEndian.Uint64(header[6:])
+	serial = binary.LittleEndian.Uint32(header[14:])
+	pageIndex = binary.LittleEndian.Uint32(header[18:])
+
+	return payload, granulePos, serial, pageIndex, nil
+}
+
+// pageChecksum computes the page CRC the same way oggwriter.createPage
+// does: over the full page with the checksum field zeroed out.
+func (o *Reader) pageChecksum(header, segmentTable, payload []byte) uint32 {
+	buf := make([]byte, 0, len(header)+len(segmentTable)+len(payload))
+	buf = append(buf, header...)
+	for idx := 22; idx < 26; idx++ {
+		buf[idx] = 0
+	}
+	buf = append(buf, segmentTable...)
+	buf = append(buf, payload...)
+
+	var checksum uint32
+	for _, b := range buf {
+		checksum = (checksum << 8) ^ o.checksumTable[byte(checksum>>24)^b]
+	}
+
+	return checksum
+}
+
+// Next implements media.MediaReader, returning the next Opus packet and its
+// presentation time, skipping any non-Opus pages (such as the Skeleton
+// index's trailing EOS page) that may be multiplexed into the file.
+func (o *Reader) Next() ([]byte, time.Duration, error) {
+	for {
+		payload, granulePos, serial, _, err := o.parseNextPage()
+		if err != nil {
+			return nil, 0, err
+		}
+		if serial != o.opusSerial {
+			continue
+		}
+
+		return payload, time.Duration(granulePos) * time.Second / opusGranuleRate, nil
+	}
+}
+
+// SeekToTime implements media.MediaReader. It loads the "-offsets.json"
+// sidecar on first use, seeks to the nearest recorded byte offset at or
+// before d, then scans forward for the next valid page boundary.
+func (o *Reader) SeekToTime(d time.Duration) error {
+	if o.offsets == nil {
+		if err := o.loadOffsets(); err != nil {
+			return err
+		}
+	}
+
+	target := d.Milliseconds()
+	var best *playOffset
+	for _, entry := range o.offsets {
+		if entry == nil || entry.TimeOffset > target {
+			continue
+		}
+		if best == nil || entry.TimeOffset > best.TimeOffset {
+			best = entry
+		}
+	}
+	if best == nil {
+		return errOffsetsNotLoaded
+	}
+
+	seeker, ok := o.stream.(io.Seeker)
+	if !ok {
+		return errNoSeekableStream
+	}
+	if _, err := seeker.Seek(best.BytesOffset, io.SeekStart); err != nil {
+		return err
+	}
+
+	for {
+		if err := o.scanForPageBoundary(); err != nil {
+			return err
+		}
+		start, err := seeker.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return err
+		}
+		// A payload can contain "OggS" too: only a page whose CRC checks
+		// out is resumed from, otherwise scanning goes on past this match.
+		if _, _, _, _, err = o.parseNextPage(); !errors.Is(err, errBadPageChecksum) {
+			if err == nil {
+				_, err = seeker.Seek(start, io.SeekStart)
+			}
+			return err
+		}
+		if _, err := seeker.Seek(start+1, io.SeekStart); err != nil {
+			return err
+		}
+	}
+}
+
+// scanForPageBoundary reads forward byte-by-byte looking for the "OggS"
+// capture pattern, then rewinds to its start so the next parseNextPage call
+// reads a complete, aligned page.
+func (o *Reader) scanForPageBoundary() error {
+	matched := 0
+	buf := make([]byte, 1)
+	for {
+		if _, err := io.ReadFull(o.stream, buf); err != nil {
+			return err
+		}
+
+		if buf[0] == pageHeaderSignature[matched] {
+			matched++
+			if matched == len(pageHeaderSignature) {
+				seeker, ok := o.stream.(io.Seeker)
+				if !ok {
+					return errNoSeekableStream
+				}
+				_, err := seeker.Seek(-int64(len(pageHeaderSignature)), io.SeekCurrent)
+
+				return err
+			}
+
+			continue
+		}
+
+		// The byte that broke the match may itself start a new one (e.g.
+		// "OggOggS" — the false start "Ogg" is immediately followed by a
+		// real "OggS"), so re-test it against the pattern's first byte
+		// instead of discarding it. pageHeaderSignature has no repeated
+		// prefix, so restarting at matched=1 here is equivalent to a full
+		// KMP restart for this specific pattern.
+		if buf[0] == pageHeaderSignature[0] {
+			matched = 1
+		} else {
+			matched = 0
+		}
+	}
+}
+
+func (o *Reader) loadOffsets() error {
+	if o.offsetsFileName == "" {
+		return errOffsetsNotLoaded
+	}
+
+	data, err := os.ReadFile(o.offsetsFileName) //nolint:gosec
+	if err != nil {
+		return err
+	}
+
+	var offsets []*playOffset
+	if err := json.Unmarshal(data, &offsets); err != nil {
+		return err
+	}
+	if len(offsets) == 0 {
+		return errOffsetsNotLoaded
+	}
+
+	o.offsets = offsets
+
+	return nil
+}
+
+// Close closes the underlying stream, if it implements io.Closer.
+func (o *Reader) Close() error {
+	if closer, ok := o.stream.(io.Closer); ok {
+		return closer.Close()
+	}
+
+	return nil
+}
+
+func generateChecksumTable() *[256]uint32 {
+	var table [256]uint32
+	const poly = 0x04c11db7
+
+	for i := range table {
+		r := uint32(i) << 24
+		for j := 0; j < 8; j++ {
+			if (r & 0x80000000) != 0 {
+				r = (r << 1) ^ poly
+			} else {
+				r <<= 1
+			}
+			table[i] = (r & 0xffffffff)
+		}
+	}
+
+	return &table
+}
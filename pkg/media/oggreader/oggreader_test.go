@@ -0,0 +1,51 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package oggreader
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+// TestScanForPageBoundaryRestartsOnFalseStart is a regression test for
+// scanForPageBoundary resetting its match state to zero on any mismatch,
+// which misses a real "OggS" page whose first bytes overlap a false start
+// (e.g. "OggOggS": the false start "Ogg" is immediately followed by a real
+// "OggS" beginning one byte later).
+func TestScanForPageBoundaryRestartsOnFalseStart(t *testing.T) {
+	data := []byte("OggOggS" + "restofpage")
+	reader := &Reader{stream: bytes.NewReader(data)}
+
+	if err := reader.scanForPageBoundary(); err != nil {
+		t.Fatalf("scanForPageBoundary: %v", err)
+	}
+
+	pos, err := reader.stream.(io.Seeker).Seek(0, io.SeekCurrent)
+	if err != nil {
+		t.Fatalf("Seek: %v", err)
+	}
+
+	const wantOffset = 3 // "OggS" begins at index 3 of "OggOggS"
+	if pos != wantOffset {
+		t.Fatalf("scanForPageBoundary left the stream at offset %d, want %d", pos, wantOffset)
+	}
+}
+
+func TestScanForPageBoundaryNoFalseStart(t *testing.T) {
+	data := []byte("OggS" + "restofpage")
+	reader := &Reader{stream: bytes.NewReader(data)}
+
+	if err := reader.scanForPageBoundary(); err != nil {
+		t.Fatalf("scanForPageBoundary: %v", err)
+	}
+
+	pos, err := reader.stream.(io.Seeker).Seek(0, io.SeekCurrent)
+	if err != nil {
+		t.Fatalf("Seek: %v", err)
+	}
+	if pos != 0 {
+		t.Fatalf("scanForPageBoundary left the stream at offset %d, want 0", pos)
+	}
+}
@@ -0,0 +1,97 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package oggwriter
+
+import "encoding/binary"
+
+// FLACStream is a LogicalStream implementation for a FLAC track muxed into
+// Ogg using the "FLAC-in-Ogg" mapping (0x7F + "FLAC"), documented at
+// https://xiph.org/flac/ogg_mapping.html. OggWriter doesn't encode FLAC
+// itself, so NewFLACStream takes the encoder's raw 34-byte STREAMINFO
+// metadata block and multiplexes already-framed FLAC frames unchanged.
+type FLACStream struct {
+	streamInfo      []byte
+	samplesPerFrame uint64
+}
+
+// NewFLACStream builds a FLAC-in-Ogg LogicalStream from the encoder's
+// 34-byte STREAMINFO block (without its own metadata block header).
+// samplesPerFrame is the nominal block size each FLAC frame advances the
+// granule position by.
+func NewFLACStream(streamInfo []byte, samplesPerFrame uint64) *FLACStream {
+	return &FLACStream{streamInfo: streamInfo, samplesPerFrame: samplesPerFrame}
+}
+
+// WriteIDHeader implements LogicalStream, returning the FLAC-in-Ogg first
+// header packet: the 0x7F+"FLAC" mapping signature, native "fLaC" marker,
+// and the STREAMINFO metadata block.
+func (f *FLACStream) WriteIDHeader() []byte {
+	header := make([]byte, 17+len(f.streamInfo))
+
+	header[0] = 0x7F                           // packet type: FLAC-in-Ogg mapping
+	copy(header[1:5], "FLAC")                  // Magic Signature 'FLAC'
+	header[5] = 1                              // major version
+	header[6] = 0                              // minor version
+	binary.BigEndian.PutUint16(header[7:9], 1) // number of header packets following this one
+	copy(header[9:13], "fLaC")                 // native FLAC stream marker
+	header[13] = 0x00                          // last-metadata-block flag clear (VORBIS_COMMENT follows), block type 0 (STREAMINFO)
+	header[14] = byte(len(f.streamInfo) >> 16) // metadata block length, 24 bits big-endian
+	header[15] = byte(len(f.streamInfo) >> 8)
+	header[16] = byte(len(f.streamInfo))
+	copy(header[17:], f.streamInfo)
+
+	return header
+}
+
+// WriteCommentHeader implements LogicalStream, returning a VORBIS_COMMENT
+// metadata block as the second FLAC-in-Ogg header packet.
+func (f *FLACStream) WriteCommentHeader() []byte {
+	const vendor = "pion"
+
+	comment := make([]byte, 4+len(vendor)+4)
+	binary.LittleEndian.PutUint32(comment[0:], uint32(len(vendor)))
+	copy(comment[4:], vendor)
+	binary.LittleEndian.PutUint32(comment[4+len(vendor):], 0) // user comment list length
+
+	block := make([]byte, 4+len(comment))
+	block[0] = 0x84 // last-metadata-block flag set, block type 4 (VORBIS_COMMENT)
+	block[1] = byte(len(comment) >> 16)
+	block[2] = byte(len(comment) >> 8)
+	block[3] = byte(len(comment))
+	copy(block[4:], comment)
+
+	return block
+}
+
+// ExtraHeaders implements LogicalStream. FLAC-in-Ogg has no header packets
+// beyond the STREAMINFO and VORBIS_COMMENT blocks above.
+func (f *FLACStream) ExtraHeaders() [][]byte {
+	return nil
+}
+
+// PacketDuration implements LogicalStream, using the nominal block size
+// passed to NewFLACStream rather than parsing each frame header's block
+// size field.
+func (f *FLACStream) PacketDuration([]byte) uint64 {
+	return f.samplesPerFrame
+}
+
+// GranuleRate implements LogicalStream. FLAC's granule position is a sample
+// count at the stream's own sample rate, which STREAMINFO encodes as a
+// 20-bit big-endian field starting at byte 10 (the top 20 bits of the
+// 64-bit block that also packs channel count, bit depth and total samples).
+func (f *FLACStream) GranuleRate() (num, denom uint64) {
+	if len(f.streamInfo) < 13 {
+		return 0, 1
+	}
+
+	sampleRate := uint64(f.streamInfo[10])<<12 | uint64(f.streamInfo[11])<<4 | uint64(f.streamInfo[12])>>4
+
+	return sampleRate, 1
+}
+
+// MimeType implements LogicalStream.
+func (f *FLACStream) MimeType() string {
+	return "audio/ogg; codecs=flac"
+}
@@ -0,0 +1,45 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package oggwriter
+
+// LogicalStream is implemented by a codec mapping that OggWriter can
+// multiplex as one logical stream into the physical Ogg stream. Opus,
+// Vorbis and FLAC-in-Ogg each have their own ID/comment header layout and
+// their own notion of how far a packet advances the granule position; see
+// opusstream.go, vorbisstream.go and flacstream.go.
+type LogicalStream interface {
+	// WriteIDHeader returns the codec identification header packet that
+	// opens the logical stream's BOS page.
+	WriteIDHeader() []byte
+
+	// WriteCommentHeader returns the comment/tags packet that follows any
+	// ExtraHeaders packets.
+	WriteCommentHeader() []byte
+
+	// ExtraHeaders returns any additional header packets that must be
+	// written between the ID header and the comment header (for example,
+	// Vorbis' setup header). Most codecs return nil.
+	ExtraHeaders() [][]byte
+
+	// PacketDuration returns how far the granule position advances for
+	// one packet of audio payload.
+	PacketDuration(payload []byte) uint64
+
+	// GranuleRate returns the rate, as a numerator/denominator pair, that
+	// this stream's granule position advances at per second. Used for the
+	// Skeleton fisbone and index packets' granule rate fields, so a reader
+	// can convert a granule position back to a time without knowing the
+	// codec.
+	GranuleRate() (num, denom uint64)
+
+	// MimeType identifies the codec for the Skeleton fisbone's
+	// Content-Type message header.
+	MimeType() string
+}
+
+// payloadValidator is an optional interface a LogicalStream can implement
+// to reject malformed payloads in WriteRTPTrack before they're muxed in.
+type payloadValidator interface {
+	Validate(payload []byte) error
+}
@@ -16,54 +16,143 @@ import (
 
 	"github.com/pion/randutil"
 	"github.com/pion/rtp"
-	"github.com/pion/rtp/codecs"
+	"github.com/pion/webrtc/v3/internal/samplebuffer"
 )
 
 const (
 	pageHeaderTypeContinuationOfStream = 0x00
+	pageHeaderContinuedPacket          = 0x01
 	pageHeaderTypeBeginningOfStream    = 0x02
 	pageHeaderTypeEndOfStream          = 0x04
 	defaultPreSkip                     = 3840 // 3840 recommended in the RFC
 	idPageSignature                    = "OpusHead"
 	commentPageSignature               = "OpusTags"
 	pageHeaderSignature                = "OggS"
+
+	// opusGranuleRate is the Opus granule rate, fixed at 48kHz by RFC 7845
+	// regardless of the input sample rate.
+	opusGranuleRate = 48000
 )
 
 var (
 	errFileNotOpened    = errors.New("file not opened")
 	errInvalidNilPacket = errors.New("invalid nil packet")
+	errNilCodec         = errors.New("codec is nil")
+	errInvalidTrackID   = errors.New("invalid track id")
+	errNotOpusTrack     = errors.New("track is not an Opus track")
 )
 
-// OggWriter is used to take RTP packets and write them to an OGG on disk
+// Option configures an OggWriter constructed via New or NewWith.
+type Option func(o *OggWriter) error
+
+// WithJitterBuffer enables a samplebuffer.SampleBuffer in front of
+// WriteRTP: packets are reordered by sequence number and released in order
+// once either maxDelay has passed or maxPackets packets have accumulated,
+// whichever comes first. Use WithOnPacketLost to be notified when the
+// buffer gives up waiting for a packet. Only affects WriteRTP (track 0);
+// WriteRTPTrack always buffers for granule-time interleaving regardless
+// (see flushInterleaved).
+func WithJitterBuffer(maxDelay time.Duration, maxPackets int) Option {
+	return func(o *OggWriter) error {
+		o.jitterBuffer = samplebuffer.NewSampleBuffer(maxDelay, maxPackets)
+		o.jitterBuffer.OnLostPacket(func(sequenceNumber uint16) {
+			if o.onPacketLost != nil {
+				o.onPacketLost(sequenceNumber)
+			}
+		})
+
+		return nil
+	}
+}
+
+// WithOnPacketLost sets a callback invoked with the sequence number of
+// every RTP packet a jitter buffer enabled via WithJitterBuffer gives up
+// waiting for, so callers can request a PLI upstream. Has no effect unless
+// WithJitterBuffer is also used.
+func WithOnPacketLost(f func(sequenceNumber uint16)) Option {
+	return func(o *OggWriter) error {
+		o.onPacketLost = f
+
+		return nil
+	}
+}
+
+// logicalStream holds the per-track state OggWriter needs to multiplex a
+// LogicalStream's pages into the physical Ogg stream alongside every other
+// track, plus the Skeleton seek index.
+type logicalStream struct {
+	codec      LogicalStream
+	serial     uint32
+	pageIndex  uint32
+	granulePos uint64
+	keypoints  []skeletonKeypoint
+
+	// idHeaderOffset is the physical byte offset this track's ID header
+	// page was written at, so Close can seek back and rewrite fields in it
+	// (see opusPreSkipPageOffset) once values only known after the fact,
+	// such as an Opus track's pre-skip, are available.
+	idHeaderOffset int64
+
+	// pending holds pages built by WriteRTPTrack but not yet written to
+	// the physical stream, oldest first, so flushInterleaved can emit
+	// pages from every track in granule-time order instead of whatever
+	// order WriteRTPTrack happened to be called in.
+	pending []pendingPage
+}
+
+// pendingPage is one track's page, built and queued by WriteRTPTrack but
+// not yet written to the physical stream.
+type pendingPage struct {
+	data         []byte
+	granulePos   uint64
+	rtpTimestamp uint32
+}
+
+// OggWriter is used to take RTP packets and write them to an OGG on disk.
+// It multiplexes one or more LogicalStream tracks (see AddTrack) into a
+// single physical Ogg stream, alongside an Ogg Skeleton logical stream
+// carrying an in-band seek index.
 type OggWriter struct {
-	stream                  io.Writer
-	count                   uint64
-	fd                      *os.File
-	sampleRate              uint32
-	channelCount            uint16
-	serial                  uint32
-	pageIndex               uint32
-	checksumTable           *[256]uint32
-	previousGranulePosition uint64
-	previousTimestamp       uint32
-	lastPayloadSize         int
+	stream        io.Writer
+	count         uint64
+	fd            *os.File
+	checksumTable *[256]uint32
+
+	tracks []*logicalStream
+
+	// Ogg Skeleton logical stream, multiplexed alongside the content
+	// tracks to carry an in-band seek index. See skeleton.go.
+	skeletonSerial    uint32
+	skeletonPageIndex uint32
+
+	// Reordering, enabled via WithJitterBuffer. Only WriteRTP (track 0)
+	// goes through the buffer; every track is additionally held in its
+	// logicalStream's pending queue for granule-time interleaving (see
+	// flushInterleaved).
+	jitterBuffer *samplebuffer.SampleBuffer
+	onPacketLost func(sequenceNumber uint16)
+
+	// Track 0's presentation time, derived from its RTP timestamps rather
+	// than wall clock, used to key the "-offsets.json" sidecar.
+	lastRTPTimestamp   uint32
+	haveRTPTimestamp   bool
+	presentationTimeMs int64
 
 	// used for seek indexing
 	offsetsfileName         string
-	lastFrameTime           int64
 	timeOffsetMap           map[int64]int64
 	highestTimeOffset       int64
-	timeElapsedMilliCounter int64
 	bytesAccumulatedCounter int64
 }
 
-// New builds a new OGG Opus writer
-func New(fileName string, sampleRate uint32, channelCount uint16) (*OggWriter, error) {
+// New builds a new OGG Opus writer, adding an Opus track as track 0 for
+// backward compatibility with WriteRTP.
+func New(fileName string, sampleRate uint32, channelCount uint16, opts ...Option) (*OggWriter, error) {
 	f, err := os.Create(fileName) //nolint:gosec
 	if err != nil {
 		return nil, err
 	}
-	writer, err := NewWith(f, sampleRate, channelCount)
+	writer, err := NewWith(f, sampleRate, channelCount, opts...)
 	if err != nil {
 		return nil, f.Close()
 	}
@@ -74,25 +163,26 @@ func New(fileName string, sampleRate uint32, channelCount uint16) (*OggWriter, e
 	return writer, nil
 }
 
-// NewWith initialize a new OGG Opus writer with an io.Writer output
-func NewWith(out io.Writer, sampleRate uint32, channelCount uint16) (*OggWriter, error) {
+// NewWith initializes a new OGG Opus writer with an io.Writer output,
+// adding an Opus track as track 0 for backward compatibility with WriteRTP.
+func NewWith(out io.Writer, sampleRate uint32, channelCount uint16, opts ...Option) (*OggWriter, error) {
 	if out == nil {
 		return nil, errFileNotOpened
 	}
 
 	writer := &OggWriter{
-		stream:        out,
-		sampleRate:    sampleRate,
-		channelCount:  channelCount,
-		serial:        randutil.NewMathRandomGenerator().Uint32(),
-		checksumTable: generateChecksumTable(),
-
-		// Timestamp and Granule MUST start from 1
-		// Only headers can have 0 values
-		previousTimestamp:       1,
-		previousGranulePosition: 1,
-	}
-	if err := writer.writeHeaders(); err != nil {
+		stream:         out,
+		skeletonSerial: randutil.NewMathRandomGenerator().Uint32(),
+		checksumTable:  generateChecksumTable(),
+	}
+
+	for _, o := range opts {
+		if err := o(writer); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := writer.AddTrack(newOpusStream(sampleRate, channelCount)); err != nil {
 		return nil, err
 	}
 
@@ -122,58 +212,105 @@ func NewWith(out io.Writer, sampleRate uint32, channelCount uint16) (*OggWriter,
    Figure 1: Example Packet Organization for a Logical Ogg Opus Stream
 */
 
-func (i *OggWriter) writeHeaders() error {
-	// ID Header
-	oggIDHeader := make([]byte, 19)
+// AddTrack registers a new LogicalStream to be multiplexed into the
+// physical Ogg stream and immediately writes its BOS page, Skeleton
+// fisbone, any ExtraHeaders packets, and comment header page. AddTrack
+// must be called before any WriteRTPTrack call for a track already added,
+// so every logical stream's header page group is written in full before
+// audio data starts interleaving.
+func (i *OggWriter) AddTrack(codec LogicalStream) (int, error) {
+	if codec == nil {
+		return 0, errNilCodec
+	}
+
+	// Skeleton BOS page (fishead). This must be the very first page in
+	// the file, ahead of every content track's BOS page, so readers that
+	// understand Skeleton can find the seek index before touching any
+	// content stream.
+	if len(i.tracks) == 0 {
+		fishead := i.createPage(fisheadPacket(), pageHeaderTypeBeginningOfStream, 0, i.skeletonSerial, i.skeletonPageIndex)
+		if err := i.writeToStream(fishead); err != nil {
+			return 0, err
+		}
+		i.skeletonPageIndex++
+	}
+
+	track := &logicalStream{
+		codec:  codec,
+		serial: randutil.NewMathRandomGenerator().Uint32(),
+	}
 
-	copy(oggIDHeader[0:], idPageSignature)                          // Magic Signature 'OpusHead'
-	oggIDHeader[8] = 1                                              // Version
-	oggIDHeader[9] = uint8(i.channelCount)                          // Channel count
-	binary.LittleEndian.PutUint16(oggIDHeader[10:], defaultPreSkip) // pre-skip
-	binary.LittleEndian.PutUint32(oggIDHeader[12:], i.sampleRate)   // original sample rate, any valid sample e.g 48000
-	binary.LittleEndian.PutUint16(oggIDHeader[16:], 0)              // output gain
-	oggIDHeader[18] = 0                                             // channel map 0 = one stream: mono or stereo
+	// Recorded before the ID header page is written, so Close can seek
+	// back to it later (see opusPreSkipPageOffset).
+	track.idHeaderOffset = i.bytesAccumulatedCounter
 
 	// Reference: https://tools.ietf.org/html/rfc7845.html#page-6
 	// RFC specifies that the ID Header page should have a granule position of 0 and a Header Type set to 2 (StartOfStream)
-	data := i.createPage(oggIDHeader, pageHeaderTypeBeginningOfStream, 0, i.pageIndex)
-	if err := i.writeToStream(data); err != nil {
-		return err
+	idHeader := i.createPage(codec.WriteIDHeader(), pageHeaderTypeBeginningOfStream, 0, track.serial, track.pageIndex)
+	if err := i.writeToStream(idHeader); err != nil {
+		return 0, err
+	}
+	track.pageIndex++
+
+	// Skeleton fisbone packet describing this track. This comes after the
+	// track's own BOS page but is otherwise a normal (non-BOS) page on
+	// the skeleton logical stream.
+	granuleRateNum, granuleRateDenom := codec.GranuleRate()
+	fisbone := fisbonePacket(fisboneParams{
+		serial:           track.serial,
+		granuleRateNum:   granuleRateNum,
+		granuleRateDenom: granuleRateDenom,
+		headerPackets:    uint32(2 + len(codec.ExtraHeaders())),
+		preroll:          defaultPreSkip,
+		contentType:      codec.MimeType(),
+	})
+	fisboneData := i.createPage(fisbone, pageHeaderTypeContinuationOfStream, 0, i.skeletonSerial, i.skeletonPageIndex)
+	if err := i.writeToStream(fisboneData); err != nil {
+		return 0, err
 	}
-	i.pageIndex++
+	i.skeletonPageIndex++
 
-	// Comment Header
-	oggCommentHeader := make([]byte, 21)
-	copy(oggCommentHeader[0:], commentPageSignature)        // Magic Signature 'OpusTags'
-	binary.LittleEndian.PutUint32(oggCommentHeader[8:], 5)  // Vendor Length
-	copy(oggCommentHeader[12:], "pion")                     // Vendor name 'pion'
-	binary.LittleEndian.PutUint32(oggCommentHeader[17:], 0) // User Comment List Length
+	for _, extra := range codec.ExtraHeaders() {
+		if err := i.writeMultiPagePacket(extra, track); err != nil {
+			return 0, err
+		}
+	}
 
 	// RFC specifies that the page where the CommentHeader completes should have a granule position of 0
-	data = i.createPage(oggCommentHeader, pageHeaderTypeContinuationOfStream, 0, i.pageIndex)
-	if err := i.writeToStream(data); err != nil {
-		return err
+	commentHeader := i.createPage(codec.WriteCommentHeader(), pageHeaderTypeContinuationOfStream, 0, track.serial, track.pageIndex)
+	if err := i.writeToStream(commentHeader); err != nil {
+		return 0, err
 	}
-	i.pageIndex++
+	track.pageIndex++
 
-	return nil
+	i.tracks = append(i.tracks, track)
+
+	return len(i.tracks) - 1, nil
 }
 
 const (
 	pageHeaderSize = 27
+
+	// opusPreSkipPageOffset is the byte offset of the pre-skip field
+	// within an Opus ID header page: the 27-byte page header, one segment
+	// table byte (the 19-byte OpusHead payload always fits in a single
+	// Ogg segment), then 10 bytes into the OpusHead payload itself (RFC
+	// 7845 Section 5.1).
+	opusPreSkipPageOffset = pageHeaderSize + 1 + 10
 )
 
-func (i *OggWriter) createPage(payload []uint8, headerType uint8, granulePos uint64, pageIndex uint32) []byte {
-	i.lastPayloadSize = len(payload)
+// createPage builds one physical Ogg page for the given logical stream
+// serial and page sequence number.
+func (i *OggWriter) createPage(payload []uint8, headerType uint8, granulePos uint64, serial, pageIndex uint32) []byte {
 	nSegments := (len(payload) / 255) + 1 // A segment can be at most 255 bytes long.
 
-	page := make([]byte, pageHeaderSize+i.lastPayloadSize+nSegments)
+	page := make([]byte, pageHeaderSize+len(payload)+nSegments)
 
 	copy(page[0:], pageHeaderSignature)                 // page headers starts with 'OggS'
 	page[4] = 0                                         // Version
 	page[5] = headerType                                // 1 = continuation, 2 = beginning of stream, 4 = end of stream
 	binary.LittleEndian.PutUint64(page[6:], granulePos) // granule position
-	binary.LittleEndian.PutUint32(page[14:], i.serial)  // Bitstream serial number
+	binary.LittleEndian.PutUint32(page[14:], serial)    // Bitstream serial number
 	binary.LittleEndian.PutUint32(page[18:], pageIndex) // Page sequence number
 	page[26] = uint8(nSegments)                         // Number of segments in page.
 
@@ -197,33 +334,237 @@ func (i *OggWriter) createPage(payload []uint8, headerType uint8, granulePos uin
 	return page
 }
 
-// WriteRTP adds a new packet and writes the appropriate headers for it
+// writeMultiPagePacket splits payload across as many pages as necessary (a
+// single page can carry at most 255 segments, i.e. up to 254*255 bytes
+// leaving room for the Ogg spec's trailing zero-length segment), setting
+// the "continued packet" flag on every page after the first. This is what
+// lets a Vorbis setup header larger than one page round-trip correctly.
+func (i *OggWriter) writeMultiPagePacket(payload []byte, track *logicalStream) error {
+	const maxPayloadPerPage = 254 * 255
+
+	offset := 0
+	first := true
+	for {
+		chunk := payload[offset:]
+		continued := len(chunk) > maxPayloadPerPage
+		if continued {
+			chunk = chunk[:maxPayloadPerPage]
+		}
+
+		headerType := uint8(pageHeaderTypeContinuationOfStream)
+		if !first {
+			headerType |= pageHeaderContinuedPacket
+		}
+
+		data := i.createPage(chunk, headerType, 0, track.serial, track.pageIndex)
+		if err := i.writeToStream(data); err != nil {
+			return err
+		}
+		track.pageIndex++
+
+		offset += len(chunk)
+		first = false
+		if !continued {
+			return nil
+		}
+	}
+}
+
+// WriteRTP adds a new packet to track 0; kept as sugar for callers created
+// via New/NewWith, which only ever have the one Opus track. If
+// WithJitterBuffer was used, packets are first reordered by sequence
+// number and released once either the configured max delay or max-packet
+// high-water mark is reached.
 func (i *OggWriter) WriteRTP(packet *rtp.Packet) error {
+	if i.jitterBuffer == nil {
+		return i.WriteRTPTrack(0, packet)
+	}
+
+	if packet == nil {
+		return errInvalidNilPacket
+	}
+
+	i.jitterBuffer.Push(packet)
+	for {
+		next, ok := i.jitterBuffer.Pop()
+		if !ok {
+			return nil
+		}
+		if err := i.WriteRTPTrack(0, next); err != nil {
+			return err
+		}
+	}
+}
+
+// WriteRTPTrack adds a new packet to the given track, queuing its page and
+// flushing whatever is now ready in granule-time order (see
+// flushInterleaved).
+func (i *OggWriter) WriteRTPTrack(trackID int, packet *rtp.Packet) error {
 	if packet == nil {
 		return errInvalidNilPacket
 	}
+	if trackID < 0 || trackID >= len(i.tracks) {
+		return errInvalidTrackID
+	}
 	if len(packet.Payload) == 0 {
 		return nil
 	}
 
-	opusPacket := codecs.OpusPacket{}
-	if _, err := opusPacket.Unmarshal(packet.Payload); err != nil {
-		// Only handle Opus packets
-		return err
+	track := i.tracks[trackID]
+
+	if validator, ok := track.codec.(payloadValidator); ok {
+		if err := validator.Validate(packet.Payload); err != nil {
+			return err
+		}
 	}
 
-	payload := opusPacket.Payload[0:]
+	track.granulePos += track.codec.PacketDuration(packet.Payload)
+
+	data := i.createPage(packet.Payload, pageHeaderTypeContinuationOfStream, track.granulePos, track.serial, track.pageIndex)
+	track.pageIndex++
 
-	// Should be equivalent to sampleRate * duration
-	if i.previousTimestamp != 1 {
-		increment := packet.Timestamp - i.previousTimestamp
-		i.previousGranulePosition += uint64(increment)
+	track.pending = append(track.pending, pendingPage{data: data, granulePos: track.granulePos, rtpTimestamp: packet.Timestamp})
+
+	return i.flushInterleaved(false)
+}
+
+// maxInterleaveDepth bounds how many pages flushInterleaved will let build
+// up on one track while waiting for every other track to produce a page of
+// its own, so a track that stops sending doesn't buffer the rest of the
+// file in memory.
+const maxInterleaveDepth = 32
+
+// flushInterleaved writes out queued pages across every track in
+// granule-time order, so pages from different tracks interleave the way a
+// streaming Ogg reader expects instead of however WriteRTPTrack happened to
+// be called. It only emits a track's oldest pending page once every track
+// has at least one page queued (so an earlier, not-yet-seen page from a
+// quiet track can't be skipped over), unless force is true (used by Close
+// to drain everything) or a track's queue has reached
+// maxInterleaveDepth.
+func (i *OggWriter) flushInterleaved(force bool) error {
+	for {
+		full := force
+		var oldest *logicalStream
+		for _, track := range i.tracks {
+			if len(track.pending) == 0 {
+				continue
+			}
+			if len(track.pending) >= maxInterleaveDepth {
+				full = true
+			}
+			if oldest == nil || track.pending[0].granulePos < oldest.pending[0].granulePos {
+				oldest = track
+			}
+		}
+		if oldest == nil {
+			return nil
+		}
+
+		if !full {
+			ready := true
+			for _, track := range i.tracks {
+				if len(track.pending) == 0 {
+					ready = false
+					break
+				}
+			}
+			if !ready {
+				return nil
+			}
+		}
+
+		pkt := oldest.pending[0]
+		oldest.pending = oldest.pending[1:]
+
+		// Record a skeleton keypoint at the byte offset this page will be
+		// written to, so Close can emit an in-band seek index.
+		keypointOffset := i.bytesAccumulatedCounter
+		if err := i.writeToStream(pkt.data); err != nil {
+			return err
+		}
+		oldest.keypoints = append(oldest.keypoints, skeletonKeypoint{offset: keypointOffset, sample: pkt.granulePos})
+
+		if oldest == i.tracks[0] {
+			i.recordOffset(pkt.rtpTimestamp, keypointOffset)
+		}
+	}
+}
+
+// recordOffset updates the "-offsets.json" sidecar's time-to-byte-offset
+// map using track 0's RTP presentation time (with wraparound handled by
+// the uint32 subtraction below), rather than wall-clock time, so seeking
+// lines up with the audio timeline instead of however fast packets
+// happened to arrive. byteOffset must be the stream's byte offset before
+// this packet's page was written, not after, so the stored offset points
+// at the page carrying this packet rather than the one after it.
+func (i *OggWriter) recordOffset(rtpTimestamp uint32, byteOffset int64) {
+	if i.offsetsfileName == "" {
+		return
+	}
+
+	if i.haveRTPTimestamp {
+		i.presentationTimeMs += int64(uint32(rtpTimestamp-i.lastRTPTimestamp)) * 1000 / opusGranuleRate
+	}
+	i.lastRTPTimestamp = rtpTimestamp
+	i.haveRTPTimestamp = true
+
+	if i.timeOffsetMap == nil {
+		i.timeOffsetMap = map[int64]int64{}
+	}
+	i.timeOffsetMap[i.presentationTimeMs] = byteOffset
+	if i.presentationTimeMs > i.highestTimeOffset {
+		i.highestTimeOffset = i.presentationTimeMs
+	}
+}
+
+// BandType returns the Opus bandwidth of the most recently written packet
+// on track 0, as derived from its TOC byte. It returns BandNarrow if track
+// 0 isn't an Opus track.
+func (i *OggWriter) BandType() BandType {
+	if len(i.tracks) == 0 {
+		return BandNarrow
+	}
+	if opus, ok := i.tracks[0].codec.(*opusStream); ok {
+		return opus.bandType
+	}
+
+	return BandNarrow
+}
+
+// FrameCount returns the number of Opus frames carried by the most
+// recently written packet on track 0, as derived from its TOC byte. It
+// returns 0 if track 0 isn't an Opus track.
+func (i *OggWriter) FrameCount() int {
+	if len(i.tracks) == 0 {
+		return 0
+	}
+	if opus, ok := i.tracks[0].codec.(*opusStream); ok {
+		return opus.frameCount
+	}
+
+	return 0
+}
+
+// SetPreSkip overrides the pre-skip value (in samples at the 48kHz Opus
+// rate) advertised in the given Opus track's ID header. AddTrack writes
+// the RFC-recommended default of 3840 up front, since an encoder's actual
+// algorithmic delay is often only known once encoding is underway; call
+// SetPreSkip as soon as the real value is known, and Close will persist it
+// into the already-flushed ID header page if the underlying stream
+// supports seeking. It returns errNotOpusTrack if trackID isn't an Opus
+// track.
+func (i *OggWriter) SetPreSkip(trackID int, samples uint16) error {
+	if trackID < 0 || trackID >= len(i.tracks) {
+		return errInvalidTrackID
+	}
+	opus, ok := i.tracks[trackID].codec.(*opusStream)
+	if !ok {
+		return errNotOpusTrack
 	}
-	i.previousTimestamp = packet.Timestamp
+	opus.preSkip = samples
 
-	data := i.createPage(payload, pageHeaderTypeContinuationOfStream, i.previousGranulePosition, i.pageIndex)
-	i.pageIndex++
-	return i.writeToStream(data)
+	return nil
 }
 
 type PlayOffset struct {
@@ -238,6 +579,24 @@ func (i *OggWriter) Close() error {
 		i.stream = nil
 	}()
 
+	// Drain any packets WithJitterBuffer is still holding back waiting for
+	// a gap to fill; no further packets are coming, so process them now
+	// rather than silently dropping the tail of the recording.
+	if i.jitterBuffer != nil {
+		for _, packet := range i.jitterBuffer.Flush() {
+			if err := i.WriteRTPTrack(0, packet); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Drain every track's pending queue, in granule-time order, so the
+	// offsets index built below covers every packet actually written,
+	// including ones still waiting on another track's first page.
+	if err := i.flushInterleaved(true); err != nil {
+		return err
+	}
+
 	secondsInRecording := i.highestTimeOffset / 1000
 	wholeSecondOffsetIndex := make([]*PlayOffset, secondsInRecording)
 	for time, offset := range i.timeOffsetMap {
@@ -282,54 +641,70 @@ func (i *OggWriter) Close() error {
 		return nil
 	}
 
-	// Seek back one page, we need to update the header and generate new CRC
-	pageOffset, err := i.fd.Seek(-1*int64(i.lastPayloadSize+pageHeaderSize+1), 2)
-	if err != nil {
-		return err
-	}
+	// Append each track's EOS page and its Skeleton index's trailing EOS
+	// page group. Pages must appear in the order they're written, and the
+	// index can't be known until every keypoint has been accumulated, so
+	// both are appended here rather than rewritten in place.
+	for _, track := range i.tracks {
+		eos := i.createPage(nil, pageHeaderTypeEndOfStream, track.granulePos, track.serial, track.pageIndex)
+		if err := i.writeToStream(eos); err != nil {
+			return err
+		}
+		track.pageIndex++
 
-	payload := make([]byte, i.lastPayloadSize)
-	if _, err := i.fd.ReadAt(payload, pageOffset+pageHeaderSize+1); err != nil {
-		return err
+		granuleRateNum, granuleRateDenom := track.codec.GranuleRate()
+		indexPacket := skeletonIndexPacket(track.serial, granuleRateNum, granuleRateDenom, track.keypoints)
+		indexData := i.createPage(indexPacket, pageHeaderTypeEndOfStream, 0, i.skeletonSerial, i.skeletonPageIndex)
+		if err := i.writeToStream(indexData); err != nil {
+			return err
+		}
+		i.skeletonPageIndex++
 	}
 
-	data := i.createPage(payload, pageHeaderTypeEndOfStream, i.previousGranulePosition, i.pageIndex-1)
-	if err := i.writeToStream(data); err != nil {
-		return err
+	// Persist any pre-skip set via SetPreSkip into its Opus track's
+	// already-flushed ID header page; the value was written as the RFC
+	// default back in AddTrack, since it's usually only known once
+	// encoding is underway.
+	if ws, ok := i.stream.(io.WriteSeeker); ok {
+		for _, track := range i.tracks {
+			opus, ok := track.codec.(*opusStream)
+			if !ok {
+				continue
+			}
+
+			if _, err := ws.Seek(track.idHeaderOffset+opusPreSkipPageOffset, io.SeekStart); err != nil {
+				return err
+			}
+			preSkip := make([]byte, 2)
+			binary.LittleEndian.PutUint16(preSkip, opus.preSkip)
+			if _, err := ws.Write(preSkip); err != nil {
+				return err
+			}
+
+			// The page CRC covers the pre-skip field, so rewrite it as well.
+			idPage := i.createPage(opus.WriteIDHeader(), pageHeaderTypeBeginningOfStream, 0, track.serial, 0)
+			if _, err := ws.Seek(track.idHeaderOffset+22, io.SeekStart); err != nil {
+				return err
+			}
+			if _, err := ws.Write(idPage[22:26]); err != nil {
+				return err
+			}
+		}
 	}
 
-	// Update the last page if we are operating on files
-	// to mark it as the EOS
 	return i.fd.Close()
 }
 
-// Wraps writing to the stream and maintains state
-// so we can set values for EOS
+// writeToStream wraps writing to the stream and tracks the total bytes
+// written so far, which WriteRTPTrack uses both for Skeleton keypoints and,
+// for track 0, the "-offsets.json" sidecar (see recordOffset).
 func (i *OggWriter) writeToStream(p []byte) error {
 	if i.stream == nil {
 		return errFileNotOpened
 	}
 
-	if i.count == 0 {
-		i.lastFrameTime = time.Now().UnixMilli()
-		i.bytesAccumulatedCounter = 0
-		i.timeElapsedMilliCounter = 0
-		i.timeOffsetMap = map[int64]int64{}
-		i.timeOffsetMap[i.timeElapsedMilliCounter] = i.bytesAccumulatedCounter
-	}
-	currTime := time.Now().UnixMilli()
-	durationSinceLastFrame := uint64(currTime - i.lastFrameTime)
-
 	i.count++
-	i.lastFrameTime = currTime
-
-	// time to offset map
-	i.bytesAccumulatedCounter = i.bytesAccumulatedCounter + int64(len(p))
-	i.timeElapsedMilliCounter = i.timeElapsedMilliCounter + int64(durationSinceLastFrame)
-	i.timeOffsetMap[i.timeElapsedMilliCounter] = i.bytesAccumulatedCounter
-	if i.timeElapsedMilliCounter > i.highestTimeOffset {
-		i.highestTimeOffset = i.timeElapsedMilliCounter
-	}
+	i.bytesAccumulatedCounter += int64(len(p))
 
 	_, err := i.stream.Write(p)
 	return err
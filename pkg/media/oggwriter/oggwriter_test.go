@@ -0,0 +1,109 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package oggwriter
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/pion/rtp"
+)
+
+// fakeStream is a minimal LogicalStream used to drive flushInterleaved
+// without needing real Opus/Vorbis/FLAC payloads.
+type fakeStream struct {
+	duration uint64
+}
+
+func (f *fakeStream) WriteIDHeader() []byte            { return []byte("idhdr") }
+func (f *fakeStream) WriteCommentHeader() []byte       { return []byte("cmthdr") }
+func (f *fakeStream) ExtraHeaders() [][]byte           { return nil }
+func (f *fakeStream) PacketDuration([]byte) uint64     { return f.duration }
+func (f *fakeStream) GranuleRate() (num, denom uint64) { return 48000, 1 }
+func (f *fakeStream) MimeType() string                 { return "application/x-fake" }
+
+func newTestWriter(t *testing.T) (*OggWriter, *bytes.Buffer) {
+	t.Helper()
+
+	buf := &bytes.Buffer{}
+	writer := &OggWriter{
+		stream:         buf,
+		checksumTable:  generateChecksumTable(),
+		skeletonSerial: 1,
+	}
+
+	return writer, buf
+}
+
+// TestWriteRTPTrackInterleavesByGranuleTime verifies that WriteRTPTrack
+// holds a track's page until every other track has produced at least one
+// page of its own, then releases pages in ascending granule-position
+// order rather than call order.
+func TestWriteRTPTrackInterleavesByGranuleTime(t *testing.T) {
+	writer, _ := newTestWriter(t)
+
+	trackA, err := writer.AddTrack(&fakeStream{duration: 100})
+	if err != nil {
+		t.Fatalf("AddTrack A: %v", err)
+	}
+	trackB, err := writer.AddTrack(&fakeStream{duration: 50})
+	if err != nil {
+		t.Fatalf("AddTrack B: %v", err)
+	}
+
+	// Track A produces a page before track B has sent anything: it must
+	// be held back rather than written immediately.
+	if err := writer.WriteRTPTrack(trackA, &rtp.Packet{Payload: []byte{0x01}}); err != nil {
+		t.Fatalf("WriteRTPTrack A: %v", err)
+	}
+	if got := len(writer.tracks[trackA].pending); got != 1 {
+		t.Fatalf("track A pending = %d, want 1 (should be held back)", got)
+	}
+	if got := len(writer.tracks[trackB].pending); got != 0 {
+		t.Fatalf("track B pending = %d, want 0", got)
+	}
+
+	// Track B's page has a lower granule position (50 < 100), so once it
+	// arrives it should flush first, leaving track A still queued.
+	if err := writer.WriteRTPTrack(trackB, &rtp.Packet{Payload: []byte{0x01}}); err != nil {
+		t.Fatalf("WriteRTPTrack B: %v", err)
+	}
+	if got := len(writer.tracks[trackB].keypoints); got != 1 {
+		t.Fatalf("track B keypoints = %d, want 1 (should have flushed)", got)
+	}
+	if got := len(writer.tracks[trackA].pending); got != 1 {
+		t.Fatalf("track A pending = %d, want 1 (still waiting on track B's next page)", got)
+	}
+	if got := len(writer.tracks[trackA].keypoints); got != 0 {
+		t.Fatalf("track A keypoints = %d, want 0 (must not flush ahead of its turn)", got)
+	}
+}
+
+// TestFlushInterleavedDepthCapBoundsBuffering verifies that a track which
+// never stops producing pages doesn't buffer unboundedly while waiting on
+// a track that has gone quiet.
+func TestFlushInterleavedDepthCapBoundsBuffering(t *testing.T) {
+	writer, _ := newTestWriter(t)
+
+	trackA, err := writer.AddTrack(&fakeStream{duration: 10})
+	if err != nil {
+		t.Fatalf("AddTrack A: %v", err)
+	}
+	if _, err := writer.AddTrack(&fakeStream{duration: 10}); err != nil {
+		t.Fatalf("AddTrack B: %v", err)
+	}
+
+	for i := 0; i < maxInterleaveDepth; i++ {
+		if err := writer.WriteRTPTrack(trackA, &rtp.Packet{Payload: []byte{0x01}}); err != nil {
+			t.Fatalf("WriteRTPTrack A #%d: %v", i, err)
+		}
+	}
+
+	if got := len(writer.tracks[trackA].pending); got != maxInterleaveDepth-1 {
+		t.Fatalf("track A pending = %d, want %d (one page should have been force-flushed)", got, maxInterleaveDepth-1)
+	}
+	if got := len(writer.tracks[trackA].keypoints); got != 1 {
+		t.Fatalf("track A keypoints = %d, want 1", got)
+	}
+}
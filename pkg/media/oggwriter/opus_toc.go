@@ -0,0 +1,105 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package oggwriter
+
+import "errors"
+
+var errInvalidOpusTOC = errors.New("invalid Opus TOC byte")
+
+// BandType identifies the audio bandwidth an Opus packet was encoded at, as
+// signalled by the configuration number in its TOC byte (RFC 6716 Section
+// 3.1).
+type BandType uint8
+
+const (
+	// BandNarrow is narrowband audio (4 kHz).
+	BandNarrow BandType = iota
+	// BandMedium is mediumband audio (6 kHz), SILK-only.
+	BandMedium
+	// BandWide is wideband audio (8 kHz).
+	BandWide
+	// BandSuperWide is super-wideband audio (12 kHz).
+	BandSuperWide
+	// BandFull is fullband audio (20 kHz).
+	BandFull
+)
+
+// opusConfigInfo is the bandwidth and per-frame duration a TOC configuration
+// number maps to.
+type opusConfigInfo struct {
+	band        BandType
+	frameSizeMs float32
+}
+
+// opusConfigTable implements the frame size table from RFC 6716 Section 3.1:
+// configurations 0-11 are SILK (10/20/40/60ms), 12-15 are hybrid (10/20ms),
+// and 16-31 are CELT-only (2.5/5/10/20ms).
+var opusConfigTable = [32]opusConfigInfo{
+	// SILK-only narrowband
+	{BandNarrow, 10}, {BandNarrow, 20}, {BandNarrow, 40}, {BandNarrow, 60},
+	// SILK-only mediumband
+	{BandMedium, 10}, {BandMedium, 20}, {BandMedium, 40}, {BandMedium, 60},
+	// SILK-only wideband
+	{BandWide, 10}, {BandWide, 20}, {BandWide, 40}, {BandWide, 60},
+	// Hybrid super-wideband
+	{BandSuperWide, 10}, {BandSuperWide, 20},
+	// Hybrid fullband
+	{BandFull, 10}, {BandFull, 20},
+	// CELT-only narrowband
+	{BandNarrow, 2.5}, {BandNarrow, 5}, {BandNarrow, 10}, {BandNarrow, 20},
+	// CELT-only wideband
+	{BandWide, 2.5}, {BandWide, 5}, {BandWide, 10}, {BandWide, 20},
+	// CELT-only super-wideband
+	{BandSuperWide, 2.5}, {BandSuperWide, 5}, {BandSuperWide, 10}, {BandSuperWide, 20},
+	// CELT-only fullband
+	{BandFull, 2.5}, {BandFull, 5}, {BandFull, 10}, {BandFull, 20},
+}
+
+// opusTOC is the decoded form of the TOC byte that begins every Opus packet,
+// plus whatever follows it (RFC 6716 Section 3.1) is needed to know how many
+// frames the packet carries.
+type opusTOC struct {
+	band        BandType
+	frameSizeMs float32
+	stereo      bool
+	frameCount  int
+}
+
+// parseOpusTOC decodes the TOC byte at payload[0] and, for packet code 3
+// (VBR/CBR multi-frame), the frame-count byte that follows it.
+func parseOpusTOC(payload []byte) (opusTOC, error) {
+	if len(payload) == 0 {
+		return opusTOC{}, errInvalidOpusTOC
+	}
+
+	toc := payload[0]
+	config := toc >> 3
+	stereo := (toc>>2)&0x01 == 1
+	code := toc & 0x03
+
+	info := opusConfigTable[config]
+
+	var frameCount int
+	switch code {
+	case 0:
+		frameCount = 1
+	case 1, 2:
+		frameCount = 2
+	default: // code == 3
+		if len(payload) < 2 {
+			return opusTOC{}, errInvalidOpusTOC
+		}
+		frameCount = int(payload[1] & 0x3F)
+		if frameCount == 0 {
+			return opusTOC{}, errInvalidOpusTOC
+		}
+	}
+
+	return opusTOC{
+		band:        info.band,
+		frameSizeMs: info.frameSizeMs,
+		stereo:      stereo,
+		frameCount:  frameCount,
+	}, nil
+}
@@ -0,0 +1,61 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package oggwriter
+
+import "testing"
+
+func TestParseOpusTOC(t *testing.T) {
+	cases := []struct {
+		name    string
+		payload []byte
+		want    opusTOC
+	}{
+		{
+			name:    "SILK narrowband, mono, single frame",
+			payload: []byte{0x00}, // config 0, stereo 0, code 0
+			want:    opusTOC{band: BandNarrow, frameSizeMs: 10, stereo: false, frameCount: 1},
+		},
+		{
+			name:    "CELT fullband, stereo, two frames (code 1)",
+			payload: []byte{0xfd}, // config 31, stereo 1, code 1
+			want:    opusTOC{band: BandFull, frameSizeMs: 20, stereo: true, frameCount: 2},
+		},
+		{
+			name:    "hybrid super-wideband, mono, multi-frame (code 3)",
+			payload: []byte{0x63, 0x05}, // config 12, stereo 0, code 3, frame count 5
+			want:    opusTOC{band: BandSuperWide, frameSizeMs: 10, stereo: false, frameCount: 5},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := parseOpusTOC(c.payload)
+			if err != nil {
+				t.Fatalf("parseOpusTOC(%#v): unexpected error: %v", c.payload, err)
+			}
+			if got != c.want {
+				t.Fatalf("parseOpusTOC(%#v) = %+v, want %+v", c.payload, got, c.want)
+			}
+		})
+	}
+}
+
+func TestParseOpusTOCErrors(t *testing.T) {
+	cases := []struct {
+		name    string
+		payload []byte
+	}{
+		{"empty payload", []byte{}},
+		{"code 3 missing frame count byte", []byte{0x03}},
+		{"code 3 zero frame count", []byte{0x03, 0x00}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if _, err := parseOpusTOC(c.payload); err == nil {
+				t.Fatalf("parseOpusTOC(%#v): expected error, got nil", c.payload)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package oggwriter
+
+import (
+	"encoding/binary"
+
+	"github.com/pion/rtp/codecs"
+)
+
+// opusStream is the LogicalStream New/NewWith use to stay backward
+// compatible: a single Opus track, per RFC 7845.
+type opusStream struct {
+	sampleRate   uint32
+	channelCount uint16
+
+	// Derived from the most recently written packet's Opus TOC byte, and
+	// exposed by OggWriter.BandType/FrameCount.
+	bandType   BandType
+	frameCount int
+
+	// preSkip is the number of samples (at the 48kHz Opus rate) a decoder
+	// should discard from the start of this stream, written into the ID
+	// header. Defaults to the RFC 7845 Section 4.2-recommended 3840 but can
+	// be corrected via OggWriter.SetPreSkip once an encoder's actual
+	// algorithmic delay is known.
+	preSkip uint16
+}
+
+func newOpusStream(sampleRate uint32, channelCount uint16) *opusStream {
+	return &opusStream{sampleRate: sampleRate, channelCount: channelCount, preSkip: defaultPreSkip}
+}
+
+// WriteIDHeader implements LogicalStream.
+func (o *opusStream) WriteIDHeader() []byte {
+	header := make([]byte, 19)
+
+	copy(header[0:], idPageSignature)                        // Magic Signature 'OpusHead'
+	header[8] = 1                                            // Version
+	header[9] = uint8(o.channelCount)                        // Channel count
+	binary.LittleEndian.PutUint16(header[10:], o.preSkip)    // pre-skip
+	binary.LittleEndian.PutUint32(header[12:], o.sampleRate) // original sample rate, any valid sample e.g 48000
+	binary.LittleEndian.PutUint16(header[16:], 0)            // output gain
+	header[18] = 0                                           // channel map 0 = one stream: mono or stereo
+
+	return header
+}
+
+// WriteCommentHeader implements LogicalStream.
+func (o *opusStream) WriteCommentHeader() []byte {
+	header := make([]byte, 21)
+
+	copy(header[0:], commentPageSignature)        // Magic Signature 'OpusTags'
+	binary.LittleEndian.PutUint32(header[8:], 5)  // Vendor Length
+	copy(header[12:], "pion")                     // Vendor name 'pion'
+	binary.LittleEndian.PutUint32(header[17:], 0) // User Comment List Length
+
+	return header
+}
+
+// ExtraHeaders implements LogicalStream. Opus has no header packets beyond
+// the ID and comment headers.
+func (o *opusStream) ExtraHeaders() [][]byte {
+	return nil
+}
+
+// Validate implements payloadValidator, rejecting payloads that don't
+// unmarshal as Opus or whose TOC byte is malformed.
+func (o *opusStream) Validate(payload []byte) error {
+	opusPacket := codecs.OpusPacket{}
+	if _, err := opusPacket.Unmarshal(payload); err != nil {
+		return err
+	}
+
+	_, err := parseOpusTOC(payload)
+
+	return err
+}
+
+// PacketDuration implements LogicalStream, deriving the granule advance
+// from the packet's Opus TOC byte (RFC 6716 Section 3.1) rather than the
+// RTP timestamp, which is fragile across packet loss and reordering.
+func (o *opusStream) PacketDuration(payload []byte) uint64 {
+	toc, err := parseOpusTOC(payload)
+	if err != nil {
+		return 0
+	}
+	o.bandType = toc.band
+	o.frameCount = toc.frameCount
+
+	// The Opus granule rate is always 48kHz, regardless of the input
+	// sample rate, so duration in ms converts to granule units by *48.
+	durationMs := float32(toc.frameCount) * toc.frameSizeMs
+
+	return uint64(durationMs * 48)
+}
+
+// GranuleRate implements LogicalStream. Opus's granule rate is fixed at
+// 48kHz by RFC 7845 regardless of the input sample rate.
+func (o *opusStream) GranuleRate() (num, denom uint64) {
+	return opusGranuleRate, 1
+}
+
+// MimeType implements LogicalStream.
+func (o *opusStream) MimeType() string {
+	return "audio/ogg; codecs=opus"
+}
@@ -0,0 +1,118 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package oggwriter
+
+import "encoding/binary"
+
+// Ogg Skeleton 4.0 (https://wiki.xiph.org/Ogg_Skeleton_4) is the standard
+// Xiph mechanism for carrying an in-band seek index alongside one or more
+// content logical streams in the same physical Ogg stream. OggWriter
+// multiplexes a single skeleton logical stream next to its content tracks:
+// its BOS page (fishead) is the very first page in the file, a fisbone
+// packet describes each track, and one index packet per track listing
+// (byte offset, sample number) keypoints is appended as a trailing page
+// group once the recording is closed.
+const (
+	fisheadSignature = "fishead\x00"
+	fisboneSignature = "fisbone\x00"
+	indexSignature   = "index\x00\x00\x00"
+
+	fisheadPacketSize       = 80
+	fisboneMessageHeaderOff = 52
+)
+
+// skeletonKeypoint records that the content page starting at byte offset
+// Offset carries the granule position (sample number) Sample, so a
+// reader can seek to the nearest keypoint at or before a target sample.
+type skeletonKeypoint struct {
+	offset int64
+	sample uint64
+}
+
+// fisheadPacket builds the fishead packet that opens the skeleton logical
+// stream. OggWriter doesn't know the presentation/base time of the
+// recording up front, so the rationals are left at 0/0 ("not specified"
+// per the spec) and only the packet framing is filled in.
+func fisheadPacket() []byte {
+	packet := make([]byte, fisheadPacketSize)
+	copy(packet[0:], fisheadSignature)            // Magic Signature 'fishead\0'
+	binary.LittleEndian.PutUint16(packet[8:], 4)  // Version major
+	binary.LittleEndian.PutUint16(packet[10:], 0) // Version minor
+	// Presentation time, base time and the segment length/content-offset
+	// fields all default to zero, meaning "unknown" for this stream.
+	return packet
+}
+
+// fisboneParams describes the content logical stream a fisbone packet
+// refers to.
+type fisboneParams struct {
+	serial           uint32
+	granuleRateNum   uint64
+	granuleRateDenom uint64
+	headerPackets    uint32
+	preroll          uint32
+	granuleShift     uint8
+	contentType      string
+}
+
+// fisbonePacket builds the fisbone packet that describes one concurrent
+// content logical stream (one per track added via AddTrack) multiplexed
+// into the physical Ogg stream.
+func fisbonePacket(p fisboneParams) []byte {
+	messageHeaders := "Content-Type: " + p.contentType + "\r\n"
+
+	packet := make([]byte, fisboneMessageHeaderOff+len(messageHeaders))
+	copy(packet[0:], fisboneSignature)                                 // Magic Signature 'fisbone\0'
+	binary.LittleEndian.PutUint32(packet[8:], fisboneMessageHeaderOff) // Offset of the message header fields
+	binary.LittleEndian.PutUint32(packet[12:], p.serial)               // Serialno of the content stream
+	binary.LittleEndian.PutUint32(packet[16:], p.headerPackets)        // Number of header packets for the content stream
+	binary.LittleEndian.PutUint64(packet[20:], p.granuleRateNum)       // Granule rate numerator
+	binary.LittleEndian.PutUint64(packet[28:], p.granuleRateDenom)     // Granule rate denominator
+	binary.LittleEndian.PutUint64(packet[36:], 0)                      // Start granule
+	binary.LittleEndian.PutUint32(packet[44:], p.preroll)              // Preroll
+	packet[48] = p.granuleShift                                        // Granule shift
+
+	copy(packet[fisboneMessageHeaderOff:], messageHeaders)
+	return packet
+}
+
+// appendSkeletonVarint appends v to buf using the variable-length encoding
+// used by Skeleton 4 index packets: 7 bits of value per byte, least
+// significant group first, with the high bit set on every byte except the
+// last one.
+func appendSkeletonVarint(buf []byte, v uint64) []byte {
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if v == 0 {
+			return append(buf, b)
+		}
+		buf = append(buf, b|0x80)
+	}
+}
+
+// skeletonIndexPacket builds the index packet for a content logical stream,
+// delta-encoding the keypoints accumulated over the recording as described
+// in the Skeleton 4 specification.
+func skeletonIndexPacket(serial uint32, granuleRateNum, granuleRateDenom uint64, keypoints []skeletonKeypoint) []byte {
+	header := make([]byte, 44)
+	copy(header[0:], indexSignature)                                   // Magic Signature 'index\0\0\0'
+	binary.LittleEndian.PutUint32(header[8:], serial)                  // Serialno of the content stream
+	binary.LittleEndian.PutUint64(header[12:], uint64(len(keypoints))) // Number of keypoints
+	binary.LittleEndian.PutUint64(header[20:], granuleRateNum)         // Granule rate numerator
+	binary.LittleEndian.PutUint64(header[28:], granuleRateDenom)       // Granule rate denominator
+	if len(keypoints) > 0 {
+		binary.LittleEndian.PutUint64(header[36:], keypoints[0].sample) // First sample number
+	}
+
+	var lastOffset int64
+	var lastSample uint64
+	for _, kp := range keypoints {
+		header = appendSkeletonVarint(header, uint64(kp.offset-lastOffset))
+		header = appendSkeletonVarint(header, kp.sample-lastSample)
+		lastOffset, lastSample = kp.offset, kp.sample
+	}
+
+	return header
+}
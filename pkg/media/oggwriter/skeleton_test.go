@@ -0,0 +1,68 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package oggwriter
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/pion/rtp"
+)
+
+func TestAppendSkeletonVarint(t *testing.T) {
+	cases := []struct {
+		name string
+		in   uint64
+		want []byte
+	}{
+		{"zero", 0, []byte{0x00}},
+		{"maxSingleByte", 0x7f, []byte{0x7f}},
+		{"needsContinuation", 0x80, []byte{0x80, 0x01}},
+		{"threeHundred", 300, []byte{0xac, 0x02}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := appendSkeletonVarint(nil, c.in)
+			if !bytes.Equal(got, c.want) {
+				t.Fatalf("appendSkeletonVarint(nil, %d) = %#v, want %#v", c.in, got, c.want)
+			}
+		})
+	}
+}
+
+// TestWriteRTPTrackRecordsOffsetAtPageStart is a regression test for an
+// off-by-one where recordOffset was called with the byte offset after the
+// current page had already been written, so every "-offsets.json" entry
+// pointed at the following page instead of the one carrying that
+// presentation time.
+func TestWriteRTPTrackRecordsOffsetAtPageStart(t *testing.T) {
+	buf := &bytes.Buffer{}
+	writer, err := NewWith(buf, 48000, 1)
+	if err != nil {
+		t.Fatalf("NewWith: %v", err)
+	}
+	writer.offsetsfileName = "unused"
+
+	for i := uint32(0); i < 3; i++ {
+		packet := &rtp.Packet{Header: rtp.Header{Timestamp: i * 960}, Payload: []byte{0x00}}
+		if err := writer.WriteRTPTrack(0, packet); err != nil {
+			t.Fatalf("WriteRTPTrack: %v", err)
+		}
+	}
+
+	if len(writer.timeOffsetMap) == 0 {
+		t.Fatal("expected at least one recorded offset")
+	}
+
+	data := buf.Bytes()
+	for presentationMs, offset := range writer.timeOffsetMap {
+		if offset < 0 || int(offset)+len(pageHeaderSignature) > len(data) {
+			t.Fatalf("offset %d for time %dms is out of range of the %d written bytes", offset, presentationMs, len(data))
+		}
+		if got := string(data[offset : offset+int64(len(pageHeaderSignature))]); got != pageHeaderSignature {
+			t.Fatalf("offset %d for time %dms does not point at a page start, got %q", offset, presentationMs, got)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package oggwriter
+
+import "encoding/binary"
+
+// VorbisStream is a LogicalStream implementation for a Vorbis track, per
+// https://xiph.org/vorbis/doc/Vorbis_I_spec.html Section 4.2. OggWriter
+// doesn't parse or encode Vorbis codebooks itself, so NewVorbisStream takes
+// the setup packet the encoder already produced and multiplexes it as-is;
+// it may span multiple pages once written, since OggWriter splits any
+// packet larger than a page can hold.
+//
+// Vorbis advances its granule position by the block size the decoder used
+// for each packet, which in turn depends on the previous packet's block
+// size and the mode signalled by bits read against the setup header's mode
+// table. Decoding that table is out of scope here, so PacketDuration uses
+// samplesPerPacket, a fixed nominal block size supplied by the caller; this
+// matches most encoders, which use a constant block size in practice.
+type VorbisStream struct {
+	sampleRate       uint32
+	channelCount     uint8
+	setupHeader      []byte
+	samplesPerPacket uint64
+}
+
+// NewVorbisStream builds a Vorbis LogicalStream. setupHeader is the raw
+// Vorbis setup packet (codebooks, floors, residues, mappings, modes)
+// produced by the encoder. samplesPerPacket is the nominal number of
+// samples each audio packet advances the granule position by.
+func NewVorbisStream(sampleRate uint32, channelCount uint8, setupHeader []byte, samplesPerPacket uint64) *VorbisStream {
+	return &VorbisStream{
+		sampleRate:       sampleRate,
+		channelCount:     channelCount,
+		setupHeader:      setupHeader,
+		samplesPerPacket: samplesPerPacket,
+	}
+}
+
+// WriteIDHeader implements LogicalStream.
+func (v *VorbisStream) WriteIDHeader() []byte {
+	header := make([]byte, 30)
+
+	header[0] = 1                                            // packet type: identification
+	copy(header[1:7], "vorbis")                              // Magic Signature 'vorbis'
+	binary.LittleEndian.PutUint32(header[7:], 0)             // vorbis_version
+	header[11] = v.channelCount                              // audio_channels
+	binary.LittleEndian.PutUint32(header[12:], v.sampleRate) // audio_sample_rate
+	binary.LittleEndian.PutUint32(header[16:], 0)            // bitrate_maximum, unset
+	binary.LittleEndian.PutUint32(header[20:], 0)            // bitrate_nominal, unset
+	binary.LittleEndian.PutUint32(header[24:], 0)            // bitrate_minimum, unset
+	header[28] = 0xB8                                        // blocksize_0=256, blocksize_1=2048 (common default)
+	header[29] = 1                                           // framing bit
+
+	return header
+}
+
+// WriteCommentHeader implements LogicalStream.
+func (v *VorbisStream) WriteCommentHeader() []byte {
+	const vendor = "pion"
+
+	header := make([]byte, 7+4+len(vendor)+4+1)
+	header[0] = 3                                                  // packet type: comment
+	copy(header[1:7], "vorbis")                                    // Magic Signature 'vorbis'
+	binary.LittleEndian.PutUint32(header[7:], uint32(len(vendor))) // vendor length
+	copy(header[11:], vendor)                                      // vendor string
+	binary.LittleEndian.PutUint32(header[11+len(vendor):], 0)      // user comment list length
+	header[len(header)-1] = 1                                      // framing bit
+
+	return header
+}
+
+// ExtraHeaders implements LogicalStream, returning the setup packet as the
+// sole extra header.
+func (v *VorbisStream) ExtraHeaders() [][]byte {
+	if len(v.setupHeader) == 0 {
+		return nil
+	}
+
+	return [][]byte{v.setupHeader}
+}
+
+// PacketDuration implements LogicalStream. See the VorbisStream doc comment
+// for why this is a fixed nominal value rather than a true per-packet
+// block-size decode.
+func (v *VorbisStream) PacketDuration([]byte) uint64 {
+	return v.samplesPerPacket
+}
+
+// GranuleRate implements LogicalStream. Vorbis's granule position is a
+// sample count at the stream's own sample rate.
+func (v *VorbisStream) GranuleRate() (num, denom uint64) {
+	return uint64(v.sampleRate), 1
+}
+
+// MimeType implements LogicalStream.
+func (v *VorbisStream) MimeType() string {
+	return "audio/ogg; codecs=vorbis"
+}
@@ -0,0 +1,25 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package media
+
+import "time"
+
+// MediaReader is implemented by container readers, such as ivfreader.Reader
+// and oggreader.Reader, that iterate the frames/pages of a file written by
+// this module's writers and can seek to an approximate presentation time
+// using the index written alongside it.
+type MediaReader interface {
+	// Next returns the next decodable unit (an IVF frame payload, or an
+	// Ogg page's packet data) along with its presentation time. It
+	// returns io.EOF once the underlying stream is exhausted.
+	Next() ([]byte, time.Duration, error)
+
+	// SeekToTime seeks the underlying stream to the nearest indexed byte
+	// offset at or before d, so the next call to Next resumes decoding
+	// from there.
+	SeekToTime(d time.Duration) error
+
+	// Close closes the underlying stream, if it implements io.Closer.
+	Close() error
+}